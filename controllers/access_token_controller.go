@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"errors"
+	"mygram-api/dto"
+	"mygram-api/helpers"
+	"mygram-api/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// toAccessTokenResponse maps a personal access token model to its response DTO
+func toAccessTokenResponse(pat models.PersonalAccessToken) dto.AccessTokenResponse {
+	return dto.AccessTokenResponse{
+		ID:         pat.ID.String(),
+		Name:       pat.Name,
+		Prefix:     pat.Prefix,
+		Scopes:     helpers.SplitScopes(pat.Scopes),
+		ExpiresAt:  pat.ExpiresAt,
+		LastUsedAt: pat.LastUsedAt,
+		CreatedAt:  pat.CreatedAt,
+	}
+}
+
+// CreateAccessToken godoc
+// @Summary Create a personal access token
+// @Description Creates a named, scoped token for scripts and integrations. The token is shown only once. Requires JWT token.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body dto.AccessTokenCreateRequest true "Token name, scopes and optional expiry"
+// @Success 201 {object} dto.BaseResponseSuccessWithData{data=dto.AccessTokenCreateResponse}
+// @Failure 400 {object} dto.BaseResponseError "Invalid request or unknown scope"
+// @Failure 401 {object} dto.BaseResponseError "Unauthorized"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/tokens [post]
+func (u *UserController) CreateAccessToken(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	var req dto.AccessTokenCreateRequest
+
+	// 1. Binding dan Validasi Request Body
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !helpers.IsValidScope(scope) {
+			c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+				Success: false,
+				Message: "Unknown scope: " + scope + ". Allowed scopes: " + strings.Join(helpers.PersonalAccessTokenScopes, ", "),
+			})
+			return
+		}
+	}
+
+	// 2. Generate token (hanya hash yang disimpan)
+	raw, err := helpers.NewPersonalAccessToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to generate token",
+		})
+		return
+	}
+
+	pat := models.PersonalAccessToken{
+		UserID:    userID,
+		Name:      req.Name,
+		TokenHash: helpers.HashOpaqueToken(raw),
+		Prefix:    raw[:len(helpers.PersonalAccessTokenPrefix)+4],
+		Scopes:    strings.Join(req.Scopes, ","),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		pat.ExpiresAt = &expiresAt
+	}
+
+	// 3. Save to DB
+	if err := u.DB.Create(&pat).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to create token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Token created successfully. Copy it now, it will not be shown again",
+		Data: dto.AccessTokenCreateResponse{
+			AccessTokenResponse: toAccessTokenResponse(pat),
+			Token:               raw,
+		},
+	})
+}
+
+// ListAccessTokens godoc
+// @Summary List personal access tokens
+// @Description Lists the authenticated user's active personal access tokens. Requires JWT token.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=[]dto.AccessTokenResponse}
+// @Failure 401 {object} dto.BaseResponseError "Unauthorized"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/tokens [get]
+func (u *UserController) ListAccessTokens(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	var tokens []models.PersonalAccessToken
+	if err := u.DB.Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at DESC").Find(&tokens).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve tokens",
+		})
+		return
+	}
+
+	respList := []dto.AccessTokenResponse{}
+	for _, pat := range tokens {
+		respList = append(respList, toAccessTokenResponse(pat))
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Tokens retrieved successfully",
+		Data:    respList,
+	})
+}
+
+// RevokeAccessToken godoc
+// @Summary Revoke a personal access token
+// @Description Revokes one of the authenticated user's personal access tokens. Requires JWT token.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param tokenID path string true "Token ID"
+// @Success 200 {object} dto.BaseResponseSuccess
+// @Failure 400 {object} dto.BaseResponseError "Invalid ID format"
+// @Failure 404 {object} dto.BaseResponseError "Token not found"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/tokens/{tokenID} [delete]
+func (u *UserController) RevokeAccessToken(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	tokenID, err := uuid.Parse(c.Param("tokenID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid token ID",
+		})
+		return
+	}
+
+	// Hanya token milik user sendiri yang bisa di-revoke
+	var pat models.PersonalAccessToken
+	if err := u.DB.First(&pat, "id = ? AND user_id = ? AND revoked_at IS NULL", tokenID, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.BaseResponseError{
+				Success: false,
+				Message: "Token not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve token",
+		})
+		return
+	}
+
+	if err := u.DB.Model(&pat).Update("revoked_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to revoke token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccess{
+		Success: true,
+		Message: "Token revoked successfully",
+	})
+}
@@ -0,0 +1,519 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"mygram-api/dto"
+	"mygram-api/helpers"
+	"mygram-api/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AdminController menyimpan dependensi DB untuk endpoint /admin
+type AdminController struct {
+	DB     *gorm.DB
+	Logger *log.Logger
+}
+
+// NewAdminController adalah constructor yang menerima dependensi DB
+func NewAdminController(db *gorm.DB, appLogger *log.Logger) *AdminController {
+	return &AdminController{
+		DB:     db,
+		Logger: appLogger,
+	}
+}
+
+// toAdminUserResponse maps a user model to the admin response DTO
+func toAdminUserResponse(user models.User) dto.AdminUserResponse {
+	return dto.AdminUserResponse{
+		ID:               user.ID.String(),
+		Username:         user.Username,
+		Email:            user.Email,
+		EmailVerifiedAt:  user.EmailVerifiedAt,
+		Age:              user.Age,
+		Role:             user.Role,
+		SuspendedAt:      user.SuspendedAt,
+		SuspendedUntil:   user.SuspendedUntil,
+		SuspensionReason: user.SuspensionReason,
+		CreatedAt:        user.CreatedAt,
+		UpdatedAt:        user.UpdatedAt,
+	}
+}
+
+// loadTargetUser reads the :userID path parameter and loads that user.
+// It writes the error response and returns false when the user cannot be loaded.
+func (a *AdminController) loadTargetUser(c *gin.Context) (models.User, bool) {
+	var user models.User
+
+	userID, err := uuid.Parse(c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return user, false
+	}
+
+	if err := a.DB.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.BaseResponseError{
+				Success: false,
+				Message: "User not found",
+			})
+			return user, false
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve user",
+		})
+		return user, false
+	}
+	return user, true
+}
+
+// isSelf reports whether the admin is acting on their own account, and rejects the request if so
+func isSelf(c *gin.Context, user models.User, action string) bool {
+	userData := c.MustGet("userData").(map[string]any)
+	if user.ID.String() != userData["id"].(string) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+		Success: false,
+		Message: "You cannot " + action + " your own account",
+	})
+	return true
+}
+
+// SetUserRole godoc
+// @Summary Change a user's role
+// @Description Sets the role (user, moderator, admin) of a user. The user's current access tokens are revoked so the new role applies right away. Requires admin role.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param userID path string true "User ID"
+// @Param body body dto.UserRoleUpdateRequest true "New role"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.AdminUserResponse}
+// @Failure 400 {object} dto.BaseResponseError "Invalid request or own role"
+// @Failure 403 {object} dto.BaseResponseError "Not an admin"
+// @Failure 404 {object} dto.BaseResponseError "User not found"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /admin/users/{userID}/role [put]
+func (a *AdminController) SetUserRole(c *gin.Context) {
+	var req dto.UserRoleUpdateRequest
+
+	// 1. Binding dan Validasi Request Body
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// 2. Cari user; admin tidak boleh mengubah role sendiri (mencegah tidak ada admin tersisa)
+	user, ok := a.loadTargetUser(c)
+	if !ok || isSelf(c, user, "change the role of") {
+		return
+	}
+
+	// 3. Update role dan catat di audit log dalam satu transaksi
+	previousRole := user.Role
+	err := a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("role", req.Role).Error; err != nil {
+			return err
+		}
+		return helpers.RecordAudit(c, tx, models.AuditLog{
+			Action:       "admin.set_role",
+			ResourceType: "user",
+			ResourceID:   user.ID.String(),
+			TargetUserID: &user.ID,
+			Details:      previousRole + " -> " + req.Role,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to update role",
+		})
+		return
+	}
+
+	// 4. Access token lama masih membawa role lama: tolak, refresh token akan memberi role baru
+	if err := helpers.RevokeTokensIssuedBefore(user.ID, time.Now()); err != nil {
+		a.Logger.Printf("Failed to revoke tokens of user %s after role change: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Role updated successfully",
+		Data:    toAdminUserResponse(user),
+	})
+}
+
+// likeEscaper escapes the LIKE wildcards (and the escape character itself) in user input
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// ListUsers godoc
+// @Summary List and search users
+// @Description Lists users newest first, optionally filtered by a search term on username or email. Requires admin role.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param q query string false "Search username or email"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.AdminUserListResponse}
+// @Failure 403 {object} dto.BaseResponseError "Not an admin"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /admin/users [get]
+func (a *AdminController) ListUsers(c *gin.Context) {
+	page, limit := parsePageParams(c)
+
+	query := a.DB.Model(&models.User{})
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		// % dan _ dari input dicari sebagai karakter biasa, bukan wildcard
+		pattern := "%" + escapeLike(strings.ToLower(q)) + "%"
+		query = query.Where(`LOWER(username) LIKE ? ESCAPE '\' OR LOWER(email) LIKE ? ESCAPE '\'`, pattern, pattern)
+	}
+
+	var total int64
+	var users []models.User
+	err := query.Count(&total).Error
+	if err == nil {
+		err = query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&users).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve users",
+		})
+		return
+	}
+
+	respList := []dto.AdminUserResponse{}
+	for _, user := range users {
+		respList = append(respList, toAdminUserResponse(user))
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Users retrieved successfully",
+		Data: dto.AdminUserListResponse{
+			Users: respList,
+			Page:  page,
+			Limit: limit,
+			Total: total,
+		},
+	})
+}
+
+// GetUser godoc
+// @Summary Get a user
+// @Description Returns a user with the number of photos, comments and social media they own. Requires admin role.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param userID path string true "User ID"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.AdminUserDetailResponse}
+// @Failure 400 {object} dto.BaseResponseError "Invalid ID format"
+// @Failure 403 {object} dto.BaseResponseError "Not an admin"
+// @Failure 404 {object} dto.BaseResponseError "User not found"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /admin/users/{userID} [get]
+func (a *AdminController) GetUser(c *gin.Context) {
+	user, ok := a.loadTargetUser(c)
+	if !ok {
+		return
+	}
+
+	response := dto.AdminUserDetailResponse{AdminUserResponse: toAdminUserResponse(user)}
+	counts := []struct {
+		model any
+		count *int64
+	}{
+		{&models.Photo{}, &response.PhotoCount},
+		{&models.Comment{}, &response.CommentCount},
+		{&models.SocialMedia{}, &response.SocialMediaCount},
+	}
+	for _, item := range counts {
+		if err := a.DB.Model(item.model).Where("user_id = ?", user.ID).Count(item.count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+				Success: false,
+				Message: "Failed to retrieve user",
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "User retrieved successfully",
+		Data:    response,
+	})
+}
+
+// SuspendUser godoc
+// @Summary Suspend a user
+// @Description Suspends a user until the given time (or indefinitely) and logs them out everywhere. Suspended users cannot log in or use their tokens. Requires admin role.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param userID path string true "User ID"
+// @Param body body dto.UserSuspendRequest true "Reason and optional end date"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.AdminUserResponse}
+// @Failure 400 {object} dto.BaseResponseError "Invalid request"
+// @Failure 403 {object} dto.BaseResponseError "Not an admin"
+// @Failure 404 {object} dto.BaseResponseError "User not found"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /admin/users/{userID}/suspend [post]
+func (a *AdminController) SuspendUser(c *gin.Context) {
+	var req dto.UserSuspendRequest
+
+	// 1. Binding dan Validasi Request Body
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+	now := time.Now()
+	if req.Until != nil && !req.Until.After(now) {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Suspension end date must be in the future",
+		})
+		return
+	}
+
+	user, ok := a.loadTargetUser(c)
+	if !ok || isSelf(c, user, "suspend") {
+		return
+	}
+
+	// 2. Simpan suspensi + audit log
+	user.SuspendedAt = &now
+	user.SuspendedUntil = req.Until
+	user.SuspensionReason = req.Reason
+	err := a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]any{
+			"suspended_at":      user.SuspendedAt,
+			"suspended_until":   user.SuspendedUntil,
+			"suspension_reason": user.SuspensionReason,
+		}).Error; err != nil {
+			return err
+		}
+		return helpers.RecordAudit(c, tx, models.AuditLog{
+			Action:       "admin.suspend_user",
+			ResourceType: "user",
+			ResourceID:   user.ID.String(),
+			TargetUserID: &user.ID,
+			Details:      req.Reason,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to suspend user",
+		})
+		return
+	}
+
+	// 3. Tolak token yang masih berlaku dan logout semua sesi
+	if err := helpers.MarkUserSuspended(user); err != nil {
+		a.Logger.Printf("Failed to cache suspension of user %s: %v", user.ID, err)
+	}
+	if err := helpers.RevokeUserSessions(a.DB, user.ID); err != nil {
+		a.Logger.Printf("Failed to revoke sessions of suspended user %s: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "User suspended successfully",
+		Data:    toAdminUserResponse(user),
+	})
+}
+
+// UnsuspendUser godoc
+// @Summary Lift a user's suspension
+// @Description Lifts the suspension of a user. Requires admin role.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param userID path string true "User ID"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.AdminUserResponse}
+// @Failure 400 {object} dto.BaseResponseError "Invalid ID format"
+// @Failure 403 {object} dto.BaseResponseError "Not an admin"
+// @Failure 404 {object} dto.BaseResponseError "User not found"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /admin/users/{userID}/suspend [delete]
+func (a *AdminController) UnsuspendUser(c *gin.Context) {
+	user, ok := a.loadTargetUser(c)
+	if !ok {
+		return
+	}
+
+	err := a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]any{
+			"suspended_at":      nil,
+			"suspended_until":   nil,
+			"suspension_reason": "",
+		}).Error; err != nil {
+			return err
+		}
+		return helpers.RecordAudit(c, tx, models.AuditLog{
+			Action:       "admin.unsuspend_user",
+			ResourceType: "user",
+			ResourceID:   user.ID.String(),
+			TargetUserID: &user.ID,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to unsuspend user",
+		})
+		return
+	}
+
+	if err := helpers.ClearUserSuspended(user.ID); err != nil {
+		a.Logger.Printf("Failed to clear cached suspension of user %s: %v", user.ID, err)
+	}
+
+	user.SuspendedAt, user.SuspendedUntil, user.SuspensionReason = nil, nil, ""
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "User unsuspended successfully",
+		Data:    toAdminUserResponse(user),
+	})
+}
+
+// ForcePasswordReset godoc
+// @Summary Force a password reset
+// @Description Invalidates the user's current password, logs them out everywhere and emails them a password reset link. Requires admin role.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param userID path string true "User ID"
+// @Success 200 {object} dto.BaseResponseSuccess
+// @Failure 400 {object} dto.BaseResponseError "Invalid ID format or own account"
+// @Failure 403 {object} dto.BaseResponseError "Not an admin"
+// @Failure 404 {object} dto.BaseResponseError "User not found"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /admin/users/{userID}/password-reset [post]
+func (a *AdminController) ForcePasswordReset(c *gin.Context) {
+	// Admin mengganti password sendiri lewat /users/password, bukan dengan mengunci akunnya
+	user, ok := a.loadTargetUser(c)
+	if !ok || isSelf(c, user, "force a password reset of") {
+		return
+	}
+
+	// Password lama diganti dengan hash acak, sehingga hanya link reset yang bisa dipakai
+	unusableHash, err := helpers.HashPassword(uuid.NewString())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to hash password",
+		})
+		return
+	}
+
+	var token string
+	err = a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password", unusableHash).Error; err != nil {
+			return err
+		}
+		var err error
+		token, err = helpers.CreateUserToken(tx, user.ID, models.UserTokenPasswordReset, passwordResetTokenTTL())
+		if err != nil {
+			return err
+		}
+		return helpers.RecordAudit(c, tx, models.AuditLog{
+			Action:       "admin.force_password_reset",
+			ResourceType: "user",
+			ResourceID:   user.ID.String(),
+			TargetUserID: &user.ID,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to reset password",
+		})
+		return
+	}
+
+	if err := helpers.RevokeUserSessions(a.DB, user.ID); err != nil {
+		a.Logger.Printf("Failed to revoke sessions of user %s after forced password reset: %v", user.ID, err)
+	}
+	resetURL := helpers.BuildActionURL("PASSWORD_RESET_URL", "/reset-password", token)
+	helpers.SendPasswordResetEmail(user.Email, user.Username, resetURL)
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccess{
+		Success: true,
+		Message: "Password reset link has been sent to the user",
+	})
+}
+
+// DeleteUser godoc
+// @Summary Delete a user
+// @Description Permanently deletes a user account together with their photos, comments and social media. Requires admin role.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param userID path string true "User ID"
+// @Success 200 {object} dto.BaseResponseSuccess
+// @Failure 400 {object} dto.BaseResponseError "Invalid ID format or own account"
+// @Failure 403 {object} dto.BaseResponseError "Not an admin"
+// @Failure 404 {object} dto.BaseResponseError "User not found"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /admin/users/{userID} [delete]
+func (a *AdminController) DeleteUser(c *gin.Context) {
+	user, ok := a.loadTargetUser(c)
+	if !ok || isSelf(c, user, "delete") {
+		return
+	}
+
+	// Audit log disimpan tanpa foreign key, sehingga tetap ada setelah user dihapus
+	err := a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := helpers.RecordAudit(c, tx, models.AuditLog{
+			Action:       "admin.delete_user",
+			ResourceType: "user",
+			ResourceID:   user.ID.String(),
+			TargetUserID: &user.ID,
+			Details:      user.Username + " <" + user.Email + ">",
+		}); err != nil {
+			return err
+		}
+		return tx.Delete(&user).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to delete user",
+		})
+		return
+	}
+
+	// Access token yang masih berlaku tidak boleh dipakai lagi
+	if err := helpers.RevokeTokensIssuedBefore(user.ID, time.Now()); err != nil {
+		a.Logger.Printf("Failed to revoke tokens of deleted user %s: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccess{
+		Success: true,
+		Message: "User deleted successfully",
+	})
+}
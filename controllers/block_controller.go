@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"errors"
+	"mygram-api/dto"
+	"mygram-api/helpers"
+	"mygram-api/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// isBlockedBetween reports whether either user blocked the other
+func isBlockedBetween(db *gorm.DB, a, b uuid.UUID) (bool, error) {
+	var count int64
+	err := db.Model(&models.UserBlock{}).
+		Where("kind = ? AND ((user_id = ? AND target_id = ?) OR (user_id = ? AND target_id = ?))", models.BlockKindBlock, a, b, b, a).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// hiddenAuthorIDs returns the users whose content is hidden from the viewer:
+// users the viewer blocked or muted and users who blocked the viewer
+func hiddenAuthorIDs(db *gorm.DB, viewerID uuid.UUID) (map[uuid.UUID]bool, error) {
+	var blocks []models.UserBlock
+	if err := db.Select("user_id", "target_id", "kind").
+		Where("user_id = ? OR (target_id = ? AND kind = ?)", viewerID, viewerID, models.BlockKindBlock).
+		Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+	hidden := make(map[uuid.UUID]bool, len(blocks))
+	for _, b := range blocks {
+		if b.UserID == viewerID {
+			hidden[b.TargetID] = true
+		} else {
+			hidden[b.UserID] = true
+		}
+	}
+	return hidden, nil
+}
+
+// Block godoc
+// @Summary Block a user
+// @Description Blocks a user: removes follows in both directions and hides each other's photos from feed and explore. Calling it again is a no-op. Requires JWT token.
+// @Tags blocks
+// @Produce json
+// @Security BearerAuth
+// @Param userID path string true "User ID"
+// @Success 200 {object} dto.BaseResponseSuccess
+// @Failure 400 {object} dto.BaseResponseError "Invalid ID or blocking yourself"
+// @Failure 404 {object} dto.BaseResponseError "User not found"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/{userID}/block [post]
+func (u *UserController) Block(c *gin.Context) {
+	u.restrictUser(c, models.BlockKindBlock)
+}
+
+// Unblock godoc
+// @Summary Unblock a user
+// @Description Removes a block. Calling it again is a no-op. Requires JWT token.
+// @Tags blocks
+// @Produce json
+// @Security BearerAuth
+// @Param userID path string true "User ID"
+// @Success 200 {object} dto.BaseResponseSuccess
+// @Failure 400 {object} dto.BaseResponseError "Invalid ID format"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/{userID}/block [delete]
+func (u *UserController) Unblock(c *gin.Context) {
+	u.unrestrictUser(c, models.BlockKindBlock)
+}
+
+// Mute godoc
+// @Summary Mute a user
+// @Description Hides a user's photos from explore without unfollowing or notifying them. Calling it again is a no-op. Requires JWT token.
+// @Tags blocks
+// @Produce json
+// @Security BearerAuth
+// @Param userID path string true "User ID"
+// @Success 200 {object} dto.BaseResponseSuccess
+// @Failure 400 {object} dto.BaseResponseError "Invalid ID or muting yourself"
+// @Failure 404 {object} dto.BaseResponseError "User not found"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/{userID}/mute [post]
+func (u *UserController) Mute(c *gin.Context) {
+	u.restrictUser(c, models.BlockKindMute)
+}
+
+// Unmute godoc
+// @Summary Unmute a user
+// @Description Removes a mute. Calling it again is a no-op. Requires JWT token.
+// @Tags blocks
+// @Produce json
+// @Security BearerAuth
+// @Param userID path string true "User ID"
+// @Success 200 {object} dto.BaseResponseSuccess
+// @Failure 400 {object} dto.BaseResponseError "Invalid ID format"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/{userID}/mute [delete]
+func (u *UserController) Unmute(c *gin.Context) {
+	u.unrestrictUser(c, models.BlockKindMute)
+}
+
+// restrictUser blocks or mutes the user in the path
+func (u *UserController) restrictUser(c *gin.Context, kind string) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	targetID, err := uuid.Parse(c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+	if targetID == userID {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "You cannot " + kind + " yourself",
+		})
+		return
+	}
+
+	var target models.User
+	if err := u.DB.Select("id").First(&target, "id = ?", targetID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.BaseResponseError{
+				Success: false,
+				Message: "User not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to " + kind + " user",
+		})
+		return
+	}
+
+	// Block juga memutus follow di kedua arah (termasuk follow request yang pending)
+	err = u.DB.Transaction(func(tx *gorm.DB) error {
+		block := models.UserBlock{UserID: userID, TargetID: targetID, Kind: kind}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&block).Error; err != nil {
+			return err
+		}
+		if kind != models.BlockKindBlock {
+			return nil
+		}
+		return tx.Where("(follower_id = ? AND following_id = ?) OR (follower_id = ? AND following_id = ?)", userID, targetID, targetID, userID).
+			Delete(&models.Follow{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to " + kind + " user",
+		})
+		return
+	}
+
+	if kind == models.BlockKindBlock {
+		if err := helpers.InvalidateFeed(userID, targetID); err != nil {
+			u.Logger.Printf("Failed to invalidate feeds after block of user %s: %v", targetID, err)
+		}
+	}
+
+	message := "User blocked successfully"
+	if kind == models.BlockKindMute {
+		message = "User muted successfully"
+	}
+	c.JSON(http.StatusOK, dto.BaseResponseSuccess{
+		Success: true,
+		Message: message,
+	})
+}
+
+// unrestrictUser removes a block or mute of the user in the path
+func (u *UserController) unrestrictUser(c *gin.Context, kind string) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	targetID, err := uuid.Parse(c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	if err := u.DB.Where("user_id = ? AND target_id = ? AND kind = ?", userID, targetID, kind).Delete(&models.UserBlock{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to un" + kind + " user",
+		})
+		return
+	}
+
+	message := "User unblocked successfully"
+	if kind == models.BlockKindMute {
+		message = "User unmuted successfully"
+	}
+	c.JSON(http.StatusOK, dto.BaseResponseSuccess{
+		Success: true,
+		Message: message,
+	})
+}
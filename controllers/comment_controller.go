@@ -35,6 +35,7 @@ func NewCommentController(db *gorm.DB, appLogger *log.Logger) *CommentController
 // @Param comment body dto.CommentCreateRequest true "Comment create payload"
 // @Success 201 {object} dto.BaseResponseSuccessWithData
 // @Failure 400 {object} dto.BaseResponseError
+// @Failure 404 {object} dto.BaseResponseError
 // @Failure 500 {object} dto.BaseResponseError
 // @Security BearerAuth
 // @Router /comments [post]
@@ -64,6 +65,23 @@ func (cc *CommentController) Create(c *gin.Context) {
 		return
 	}
 
+	// Pastikan photo ada
+	var photo models.Photo
+	if err := cc.DB.Select("id").First(&photo, "id = ?", photoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.BaseResponseError{
+				Success: false,
+				Message: "Photo not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve photo",
+		})
+		return
+	}
+
 	comment := models.Comment{
 		ID:      uuid.New(),
 		UserID:  userID,
@@ -84,6 +102,7 @@ func (cc *CommentController) Create(c *gin.Context) {
 		UserID:    comment.UserID.String(),
 		PhotoID:   comment.PhotoID.String(),
 		Message:   comment.Message,
+		Reactions: map[string]int64{},
 		CreatedAt: comment.CreatedAt,
 		UpdatedAt: comment.UpdatedAt,
 	}
@@ -95,25 +114,66 @@ func (cc *CommentController) Create(c *gin.Context) {
 	})
 }
 
+// commentListQuery lists top-level comments for GET /comments
+var commentListQuery = listQuery[models.Comment]{
+	sorts: map[string]listSort[models.Comment]{
+		"created_at": {column: "created_at", kind: sortTime, value: func(cm models.Comment) any { return cm.CreatedAt }},
+		"updated_at": {column: "updated_at", kind: sortTime, value: func(cm models.Comment) any { return cm.UpdatedAt }},
+	},
+	defaultSort: "-created_at",
+	filters: map[string]listFilter{
+		"user_id":        {column: "user_id", kind: filterUUID},
+		"photo_id":       {column: "photo_id", kind: filterUUID},
+		"created_after":  {column: "created_at", kind: filterAfter},
+		"created_before": {column: "created_at", kind: filterBefore},
+	},
+	id: func(cm models.Comment) uuid.UUID { return cm.ID },
+}
+
 // GetAll godoc
 // @Summary Get all comments
-// @Description Retrieve all comments
+// @Description Retrieve top-level comments, keyset paginated: pass meta.next_cursor as ?cursor= for the next page
 // @Tags comments
 // @Produce json
-// @Success 200 {object} dto.BaseResponseSuccessWithData
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param sort query string false "created_at or updated_at; prefix with - for descending (default -created_at)"
+// @Param user_id query string false "Only comments of this user"
+// @Param photo_id query string false "Only comments on this photo"
+// @Param created_after query string false "RFC 3339 timestamp"
+// @Param created_before query string false "RFC 3339 timestamp"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=[]dto.CommentResponse,meta=dto.ListMeta}
+// @Failure 400 {object} dto.BaseResponseError
 // @Failure 500 {object} dto.BaseResponseError
 // @Security BearerAuth
 // @Router /comments [get]
 func (cc *CommentController) GetAll(c *gin.Context) {
-	var comments []models.Comment
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
 
 	// Preload User and Photo to include related data if desired
-	if err := cc.DB.
+	comments, meta, err := commentListQuery.find(c, cc.DB.
 		Where("parent_comment_id IS NULL").
-		Preload("Replies"). // preload replies untuk semua comments hasil query (batch)
 		Preload("User").
-		Preload("Photo").
-		Find(&comments).Error; err != nil {
+		Preload("Photo"))
+	if err != nil {
+		respondListError(c, err, "Failed to retrieve comments")
+		return
+	}
+
+	// Jumlah reaksi untuk semua comments sekaligus (satu query agregat)
+	ids := make([]uuid.UUID, 0, len(comments))
+	for _, cm := range comments {
+		ids = append(ids, cm.ID)
+	}
+	reactions, myReactions, err := loadCommentReactions(cc.DB, userID, ids)
+	var replyCounts map[uuid.UUID]int
+	if err == nil {
+		// Jumlah balasan dihitung dengan satu query, tanpa memuat semua balasan
+		replyCounts, err = countReplies(cc.DB, ids)
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
 			Success: false,
 			Message: "Failed to retrieve comments",
@@ -121,14 +181,16 @@ func (cc *CommentController) GetAll(c *gin.Context) {
 		return
 	}
 
-	var respList []dto.CommentResponse
+	respList := make([]dto.CommentResponse, 0, len(comments))
 	for _, cm := range comments {
 		respList = append(respList, dto.CommentResponse{
 			ID:           cm.ID.String(),
 			UserID:       cm.UserID.String(),
 			PhotoID:      cm.PhotoID.String(),
 			Message:      cm.Message,
-			RepliesCount: len(cm.Replies),
+			RepliesCount: replyCounts[cm.ID],
+			Reactions:    reactions[cm.ID],
+			MyReactions:  myReactions[cm.ID],
 			CreatedAt:    cm.CreatedAt,
 			UpdatedAt:    cm.UpdatedAt,
 		})
@@ -138,6 +200,7 @@ func (cc *CommentController) GetAll(c *gin.Context) {
 		Success: true,
 		Message: "Comments retrieved successfully",
 		Data:    respList,
+		Meta:    meta,
 	})
 }
 
@@ -212,13 +275,26 @@ func (cc *CommentController) Update(c *gin.Context) {
 		return
 	}
 
+	userData := c.MustGet("userData").(map[string]any)
+	callerID, _ := uuid.Parse(userData["id"].(string))
+	reactions, myReactions, err := loadCommentReactions(cc.DB, callerID, []uuid.UUID{comment.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve updated comment",
+		})
+		return
+	}
+
 	resp := dto.CommentResponse{
-		ID:        comment.ID.String(),
-		UserID:    comment.UserID.String(),
-		PhotoID:   comment.PhotoID.String(),
-		Message:   comment.Message,
-		CreatedAt: comment.CreatedAt,
-		UpdatedAt: comment.UpdatedAt,
+		ID:          comment.ID.String(),
+		UserID:      comment.UserID.String(),
+		PhotoID:     comment.PhotoID.String(),
+		Message:     comment.Message,
+		Reactions:   reactions[comment.ID],
+		MyReactions: myReactions[comment.ID],
+		CreatedAt:   comment.CreatedAt,
+		UpdatedAt:   comment.UpdatedAt,
 	}
 
 	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
@@ -370,6 +446,7 @@ func (cc *CommentController) CreateReply(ctx *gin.Context) {
 		Message:         reply.Message,
 		ParentCommentID: parentIDStrOut,
 		RepliesCount:    0,
+		Reactions:       map[string]int64{},
 		CreatedAt:       reply.CreatedAt,
 		UpdatedAt:       reply.UpdatedAt,
 	}
@@ -386,15 +463,15 @@ func (cc *CommentController) CreateReply(ctx *gin.Context) {
 // @Description Get replies for a given parent comment
 // @Tags comments
 // @Produce json
-// @Param parentCommentID path string true "Parent Comment ID"
+// @Param commentID path string true "Parent Comment ID"
 // @Success 200 {object} dto.BaseResponseSuccessWithData
 // @Failure 400 {object} dto.BaseResponseError
 // @Failure 404 {object} dto.BaseResponseError
 // @Failure 500 {object} dto.BaseResponseError
 // @Security BearerAuth
-// @Router /comments/{parentCommentID}/replies [get]
+// @Router /comments/{commentID}/replies [get]
 func (cc *CommentController) GetReplies(ctx *gin.Context) {
-	parentIDStr := ctx.Param("parentCommentID")
+	parentIDStr := ctx.Param("commentID")
 	parentID, err := uuid.Parse(parentIDStr)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, dto.BaseResponseError{
@@ -423,7 +500,26 @@ func (cc *CommentController) GetReplies(ctx *gin.Context) {
 
 	// Ambil semua balasan untuk parent tersebut
 	var replies []models.Comment
-	if err := cc.DB.Where("parent_comment_id = ?", parentID).Preload("User").Preload("Replies").Find(&replies).Error; err != nil {
+	if err := cc.DB.Where("parent_comment_id = ?", parentID).Preload("User").Find(&replies).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve replies",
+		})
+		return
+	}
+
+	userData := ctx.MustGet("userData").(map[string]any)
+	userID, _ := uuid.Parse(userData["id"].(string))
+	ids := make([]uuid.UUID, 0, len(replies))
+	for _, r := range replies {
+		ids = append(ids, r.ID)
+	}
+	reactions, myReactions, err := loadCommentReactions(cc.DB, userID, ids)
+	var replyCounts map[uuid.UUID]int
+	if err == nil {
+		replyCounts, err = countReplies(cc.DB, ids)
+	}
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, dto.BaseResponseError{
 			Success: false,
 			Message: "Failed to retrieve replies",
@@ -444,7 +540,9 @@ func (cc *CommentController) GetReplies(ctx *gin.Context) {
 			PhotoID:         r.PhotoID.String(),
 			Message:         r.Message,
 			ParentCommentID: parentIDOut,
-			RepliesCount:    len(r.Replies),
+			RepliesCount:    replyCounts[r.ID],
+			Reactions:       reactions[r.ID],
+			MyReactions:     myReactions[r.ID],
 			CreatedAt:       r.CreatedAt,
 			UpdatedAt:       r.UpdatedAt,
 		})
@@ -456,3 +554,183 @@ func (cc *CommentController) GetReplies(ctx *gin.Context) {
 		Data:    resp,
 	})
 }
+
+// GetByID godoc
+// @Summary Get a comment
+// @Description Get a single comment or reply. Related data can be expanded with ?include=user,photo,replies (up to 100 direct replies, oldest first).
+// @Tags comments
+// @Produce json
+// @Param commentID path string true "Comment ID"
+// @Param include query string false "Comma separated: user, photo, replies"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.CommentDetailResponse}
+// @Failure 400 {object} dto.BaseResponseError
+// @Failure 403 {object} dto.BaseResponseError "Private profile"
+// @Failure 404 {object} dto.BaseResponseError
+// @Failure 500 {object} dto.BaseResponseError
+// @Security BearerAuth
+// @Router /comments/{commentID} [get]
+func (cc *CommentController) GetByID(c *gin.Context) {
+	commentID, err := uuid.Parse(c.Param("commentID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid comment ID",
+		})
+		return
+	}
+	includes, ok := parseIncludes(c, "user", "photo", "replies")
+	if !ok {
+		return
+	}
+
+	// The photo is always loaded: its owner decides whether the caller may see the comment
+	query := cc.DB.Preload("Photo")
+	if includes["user"] {
+		query = query.Preload("User")
+	}
+	if includes["replies"] {
+		query = query.Preload("Replies", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC").Order("id ASC").Limit(maxIncludedComments)
+		})
+	}
+
+	var comment models.Comment
+	if err := query.First(&comment, "id = ?", commentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.BaseResponseError{
+				Success: false,
+				Message: "Comment not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve comment",
+		})
+		return
+	}
+
+	userData := c.MustGet("userData").(map[string]any)
+	userID, _ := uuid.Parse(userData["id"].(string))
+	if comment.Photo != nil && !requireContentVisible(c, cc.DB, userID, comment.Photo.UserID, "Comment not found") {
+		return
+	}
+	resp, err := toCommentDetails(cc.DB, userID, []models.Comment{comment}, includes["replies"])
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve comment",
+		})
+		return
+	}
+	if includes["photo"] {
+		liked, err := likedPhotoIDs(cc.DB, userID, []uuid.UUID{comment.PhotoID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+				Success: false,
+				Message: "Failed to retrieve comment",
+			})
+			return
+		}
+		photo := toPhotoResponse(*comment.Photo, liked[comment.PhotoID])
+		resp[0].Photo = &photo
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Comment retrieved successfully",
+		Data:    resp[0],
+	})
+}
+
+// toCommentDetails maps comments and their preloaded User to detail responses, with reaction summaries
+// and reply counts loaded in batch. withReplies adds the preloaded Replies (one level).
+func toCommentDetails(db *gorm.DB, viewerID uuid.UUID, comments []models.Comment, withReplies bool) ([]dto.CommentDetailResponse, error) {
+	var ids []uuid.UUID
+	for _, cm := range comments {
+		ids = append(ids, cm.ID)
+		for _, r := range cm.Replies {
+			ids = append(ids, r.ID)
+		}
+	}
+	toDetail, err := commentDetailMapper(db, viewerID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]dto.CommentDetailResponse, 0, len(comments))
+	for _, cm := range comments {
+		detail := toDetail(cm)
+		if withReplies {
+			detail.Replies = make([]dto.CommentDetailResponse, 0, len(cm.Replies))
+			for _, r := range cm.Replies {
+				detail.Replies = append(detail.Replies, toDetail(r))
+			}
+		}
+		resp = append(resp, detail)
+	}
+	return resp, nil
+}
+
+// commentDetailMapper loads the reaction summaries and reply counts of the comments ids in batch and
+// returns a function mapping one of them (with its preloaded User) to a detail response without replies
+func commentDetailMapper(db *gorm.DB, viewerID uuid.UUID, ids []uuid.UUID) (func(models.Comment) dto.CommentDetailResponse, error) {
+	reactions, myReactions, err := loadCommentReactions(db, viewerID, ids)
+	if err != nil {
+		return nil, err
+	}
+	replyCounts, err := countReplies(db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(cm models.Comment) dto.CommentDetailResponse {
+		var parentID *string
+		if cm.ParentCommentID != nil {
+			p := cm.ParentCommentID.String()
+			parentID = &p
+		}
+		detail := dto.CommentDetailResponse{
+			CommentResponse: dto.CommentResponse{
+				ID:              cm.ID.String(),
+				UserID:          cm.UserID.String(),
+				PhotoID:         cm.PhotoID.String(),
+				Message:         cm.Message,
+				ParentCommentID: parentID,
+				RepliesCount:    replyCounts[cm.ID],
+				Reactions:       reactions[cm.ID],
+				MyReactions:     myReactions[cm.ID],
+				CreatedAt:       cm.CreatedAt,
+				UpdatedAt:       cm.UpdatedAt,
+			},
+		}
+		if cm.User != nil {
+			user := toUserSummary(*cm.User)
+			detail.User = &user
+		}
+		return detail
+	}, nil
+}
+
+// countReplies counts the direct replies of each comment in one query
+func countReplies(db *gorm.DB, commentIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	counts := make(map[uuid.UUID]int, len(commentIDs))
+	if len(commentIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		ParentCommentID uuid.UUID
+		Count           int
+	}
+	if err := db.Model(&models.Comment{}).
+		Select("parent_comment_id, COUNT(*) AS count").
+		Where("parent_comment_id IN ?", commentIDs).
+		Group("parent_comment_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		counts[r.ParentCommentID] = r.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"errors"
+	"mygram-api/dto"
+	"mygram-api/helpers"
+	"mygram-api/models"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// loadCommentReactions aggregates the reaction counts of the comments and the viewer's own reactions.
+// Every requested comment gets a (possibly empty) counts map.
+func loadCommentReactions(db *gorm.DB, viewerID uuid.UUID, commentIDs []uuid.UUID) (map[uuid.UUID]map[string]int64, map[uuid.UUID][]string, error) {
+	counts := make(map[uuid.UUID]map[string]int64, len(commentIDs))
+	mine := make(map[uuid.UUID][]string)
+	for _, id := range commentIDs {
+		counts[id] = map[string]int64{}
+	}
+	if len(commentIDs) == 0 {
+		return counts, mine, nil
+	}
+
+	var rows []struct {
+		CommentID uuid.UUID
+		Type      string
+		Count     int64
+	}
+	if err := db.Model(&models.CommentReaction{}).
+		Select("comment_id, type, COUNT(*) AS count").
+		Where("comment_id IN ?", commentIDs).
+		Group("comment_id, type").
+		Scan(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, r := range rows {
+		counts[r.CommentID][r.Type] = r.Count
+	}
+
+	var own []models.CommentReaction
+	if err := db.Select("comment_id", "type").
+		Where("user_id = ? AND comment_id IN ?", viewerID, commentIDs).
+		Order("type").
+		Find(&own).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, r := range own {
+		mine[r.CommentID] = append(mine[r.CommentID], r.Type)
+	}
+	return counts, mine, nil
+}
+
+// AddReaction godoc
+// @Summary React to a comment
+// @Description Adds a reaction to a comment or reply. A user has at most one reaction per type per comment; calling it again is a no-op. Allowed types are configured with COMMENT_REACTIONS.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param commentID path string true "Comment ID"
+// @Param reaction body dto.CommentReactionRequest true "Reaction type"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.CommentReactionsResponse}
+// @Failure 400 {object} dto.BaseResponseError
+// @Failure 403 {object} dto.BaseResponseError "Blocked"
+// @Failure 404 {object} dto.BaseResponseError
+// @Failure 500 {object} dto.BaseResponseError
+// @Security BearerAuth
+// @Router /comments/{commentID}/reactions [post]
+func (cc *CommentController) AddReaction(c *gin.Context) {
+	var req dto.CommentReactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+	cc.setReaction(c, strings.ToLower(strings.TrimSpace(req.Type)), true)
+}
+
+// RemoveReaction godoc
+// @Summary Remove a reaction from a comment
+// @Description Removes the caller's reaction of the given type. Calling it again is a no-op.
+// @Tags comments
+// @Produce json
+// @Param commentID path string true "Comment ID"
+// @Param type path string true "Reaction type"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.CommentReactionsResponse}
+// @Failure 400 {object} dto.BaseResponseError
+// @Failure 404 {object} dto.BaseResponseError
+// @Failure 500 {object} dto.BaseResponseError
+// @Security BearerAuth
+// @Router /comments/{commentID}/reactions/{type} [delete]
+func (cc *CommentController) RemoveReaction(c *gin.Context) {
+	cc.setReaction(c, strings.ToLower(c.Param("type")), false)
+}
+
+// setReaction adds or removes one reaction of the caller and returns the new summary
+func (cc *CommentController) setReaction(c *gin.Context, reactionType string, add bool) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	commentID, err := uuid.Parse(c.Param("commentID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid comment ID",
+		})
+		return
+	}
+
+	// Reaksi yang sudah dihapus dari konfigurasi tetap boleh di-remove
+	if add && !helpers.IsValidCommentReaction(reactionType) {
+		allowed := append([]string(nil), helpers.CommentReactionTypes()...)
+		sort.Strings(allowed)
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Unsupported reaction type, allowed: " + strings.Join(allowed, ", "),
+		})
+		return
+	}
+
+	var comment models.Comment
+	if err := cc.DB.Select("id", "user_id").First(&comment, "id = ?", commentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.BaseResponseError{
+				Success: false,
+				Message: "Comment not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve comment",
+		})
+		return
+	}
+
+	if add {
+		blocked, err := isBlockedBetween(cc.DB, userID, comment.UserID)
+		if err == nil && blocked {
+			c.JSON(http.StatusForbidden, dto.BaseResponseError{
+				Success: false,
+				Message: "You cannot react to this comment",
+			})
+			return
+		}
+		// Unique (comment, user, type) + ON CONFLICT DO NOTHING: idempotent dan aman untuk request bersamaan
+		if err == nil {
+			err = cc.DB.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&models.CommentReaction{CommentID: commentID, UserID: userID, Type: reactionType}).Error
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+				Success: false,
+				Message: "Failed to add reaction",
+			})
+			return
+		}
+	} else if err := cc.DB.Where("comment_id = ? AND user_id = ? AND type = ?", commentID, userID, reactionType).
+		Delete(&models.CommentReaction{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to remove reaction",
+		})
+		return
+	}
+
+	counts, mine, err := loadCommentReactions(cc.DB, userID, []uuid.UUID{commentID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve reactions",
+		})
+		return
+	}
+
+	message := "Reaction added successfully"
+	if !add {
+		message = "Reaction removed successfully"
+	}
+	myReactions := mine[commentID]
+	if myReactions == nil {
+		myReactions = []string{}
+	}
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: message,
+		Data: dto.CommentReactionsResponse{
+			CommentID:   commentID.String(),
+			Reactions:   counts[commentID],
+			MyReactions: myReactions,
+		},
+	})
+}
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"mygram-api/dto"
+	"mygram-api/helpers"
+	"mygram-api/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// replyCountColumn counts the direct replies of the comments row; selected as reply_count for sorting
+const replyCountColumn = "(SELECT COUNT(*) FROM comments AS r WHERE r.parent_comment_id = comments.id)"
+
+// photoCommentListQuery lists the top-level comments of a photo for GET /photos/:photoID/comments
+var photoCommentListQuery = listQuery[models.Comment]{
+	sorts: map[string]listSort[models.Comment]{
+		"created_at":  {column: "comments.created_at", kind: sortTime, value: func(cm models.Comment) any { return cm.CreatedAt }},
+		"reply_count": {column: replyCountColumn, kind: sortInt, value: func(cm models.Comment) any { return cm.ReplyCount }},
+	},
+	presets: map[string]string{
+		"newest":       "-created_at",
+		"oldest":       "created_at",
+		"most_replied": "-reply_count",
+	},
+	defaultSort: "newest",
+	idColumn:    "comments.id",
+	id:          func(cm models.Comment) uuid.UUID { return cm.ID },
+}
+
+// ListPhotoComments godoc
+// @Summary List the comments of a photo
+// @Description Top-level comments of a photo with their author, keyset paginated: pass meta.next_cursor as ?cursor= for the next page. Replies are available through GET /comments/{commentID}/thread.
+// @Tags comments
+// @Produce json
+// @Param photoID path string true "Photo ID"
+// @Param sort query string false "newest (default), oldest or most_replied"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "Cursor from the previous page"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=[]dto.CommentDetailResponse,meta=dto.ListMeta}
+// @Failure 400 {object} dto.BaseResponseError
+// @Failure 403 {object} dto.BaseResponseError "Private profile"
+// @Failure 404 {object} dto.BaseResponseError
+// @Failure 500 {object} dto.BaseResponseError
+// @Security BearerAuth
+// @Router /photos/{photoID}/comments [get]
+func (cc *CommentController) ListPhotoComments(c *gin.Context) {
+	photoID, err := uuid.Parse(c.Param("photoID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid photo ID",
+		})
+		return
+	}
+
+	var photo models.Photo
+	if err := cc.DB.Select("id", "user_id").First(&photo, "id = ?", photoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.BaseResponseError{
+				Success: false,
+				Message: "Photo not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve photo",
+		})
+		return
+	}
+
+	userData := c.MustGet("userData").(map[string]any)
+	userID, _ := uuid.Parse(userData["id"].(string))
+	if !requireContentVisible(c, cc.DB, userID, photo.UserID, "Photo not found") {
+		return
+	}
+
+	comments, meta, err := photoCommentListQuery.find(c, cc.DB.
+		Model(&models.Comment{}).
+		Select("comments.*, "+replyCountColumn+" AS reply_count").
+		Where("comments.photo_id = ? AND comments.parent_comment_id IS NULL", photoID).
+		Preload("User"))
+	if err != nil {
+		respondListError(c, err, "Failed to retrieve comments")
+		return
+	}
+
+	resp, err := toCommentDetails(cc.DB, userID, comments, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve comments",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Comments retrieved successfully",
+		Data:    resp,
+		Meta:    meta,
+	})
+}
+
+// GetThread godoc
+// @Summary Get a comment thread
+// @Description Returns the comment with its whole reply tree (with authors) as nested replies, oldest first per level, down to ?depth= levels (default and max COMMENT_THREAD_MAX_DEPTH). At most COMMENT_THREAD_MAX_NODES comments are returned, level by level; truncated is true when more exist. A node with replies_count greater than its number of replies was cut off by one of the limits.
+// @Tags comments
+// @Produce json
+// @Param commentID path string true "Comment ID"
+// @Param depth query int false "Reply levels to load (0 = only the comment)"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.CommentThreadResponse}
+// @Failure 400 {object} dto.BaseResponseError
+// @Failure 404 {object} dto.BaseResponseError
+// @Failure 500 {object} dto.BaseResponseError
+// @Security BearerAuth
+// @Router /comments/{commentID}/thread [get]
+func (cc *CommentController) GetThread(c *gin.Context) {
+	commentID, err := uuid.Parse(c.Param("commentID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid comment ID",
+		})
+		return
+	}
+
+	maxDepth := helpers.CommentThreadMaxDepth()
+	depth := maxDepth
+	if raw := c.Query("depth"); raw != "" {
+		depth, err = strconv.Atoi(raw)
+		if err != nil || depth < 0 || depth > maxDepth {
+			c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+				Success: false,
+				Message: fmt.Sprintf("Invalid depth, must be between 0 and %d", maxDepth),
+			})
+			return
+		}
+	}
+
+	// Satu query rekursif (CTE) untuk seluruh pohon, bukan Preload("Replies") per level
+	comments, truncated, err := helpers.LoadCommentThread(cc.DB, commentID, depth, helpers.CommentThreadMaxNodes())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.BaseResponseError{
+				Success: false,
+				Message: "Comment not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve comment thread",
+		})
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(comments))
+	userIDs := make([]uuid.UUID, 0, len(comments))
+	for _, cm := range comments {
+		ids = append(ids, cm.ID)
+		userIDs = append(userIDs, cm.UserID)
+	}
+	var users []models.User
+	if err := cc.DB.Select("id", "username", "display_name", "avatar_url").
+		Where("id IN ?", userIDs).
+		Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve comment thread",
+		})
+		return
+	}
+	usersByID := make(map[uuid.UUID]*models.User, len(users))
+	for i := range users {
+		usersByID[users[i].ID] = &users[i]
+	}
+
+	userData := c.MustGet("userData").(map[string]any)
+	userID, _ := uuid.Parse(userData["id"].(string))
+	toDetail, err := commentDetailMapper(cc.DB, userID, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve comment thread",
+		})
+		return
+	}
+
+	// Hasil CTE urut per level, jadi induk selalu muncul sebelum balasannya
+	children := make(map[uuid.UUID][]models.Comment)
+	for _, cm := range comments[1:] {
+		children[*cm.ParentCommentID] = append(children[*cm.ParentCommentID], cm)
+	}
+	var build func(cm models.Comment) dto.CommentDetailResponse
+	build = func(cm models.Comment) dto.CommentDetailResponse {
+		cm.User = usersByID[cm.UserID]
+		detail := toDetail(cm)
+		detail.Replies = make([]dto.CommentDetailResponse, 0, len(children[cm.ID]))
+		for _, reply := range children[cm.ID] {
+			detail.Replies = append(detail.Replies, build(reply))
+		}
+		return detail
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Comment thread retrieved successfully",
+		Data:    dto.CommentThreadResponse{CommentDetailResponse: build(comments[0]), Truncated: truncated},
+	})
+}
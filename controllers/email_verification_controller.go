@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"errors"
+	"mygram-api/dto"
+	"mygram-api/helpers"
+	"mygram-api/models"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var errEmailAlreadyTaken = errors.New("email is already registered by another account")
+
+// emailVerificationTokenTTL returns how long a verification link stays valid (EMAIL_VERIFICATION_TOKEN_TTL, default 24 hours)
+func emailVerificationTokenTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("EMAIL_VERIFICATION_TOKEN_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return 24 * time.Hour
+}
+
+// sendEmailVerification creates a verification token for the address and emails the link to it.
+// The address may differ from user.Email when the user is changing their email; that link is an
+// email change token, so resending the verification of the current address does not invalidate it.
+func sendEmailVerification(db *gorm.DB, user models.User, email string) error {
+	createToken := helpers.CreateEmailVerificationToken
+	if email != user.Email {
+		createToken = helpers.CreateEmailChangeToken
+	}
+	token, err := createToken(db, user.ID, email, emailVerificationTokenTTL())
+	if err != nil {
+		return err
+	}
+
+	verifyURL := helpers.BuildActionURL("EMAIL_VERIFICATION_URL", "/auth/verify-email", token)
+	helpers.SendVerificationEmail(email, user.Username, verifyURL)
+	return nil
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Confirms an email address with the token sent by email. For an email change, the new address replaces the old one only now.
+// @Tags users
+// @Produce json
+// @Param token query string true "Verification token"
+// @Success 200 {object} dto.BaseResponseSuccess
+// @Failure 400 {object} dto.BaseResponseError "Missing, invalid or expired token"
+// @Failure 409 {object} dto.BaseResponseError "Email already registered by another account"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /auth/verify-email [get]
+func (u *UserController) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Token is required",
+		})
+		return
+	}
+
+	err := u.DB.Transaction(func(tx *gorm.DB) error {
+		// 1. Consume token
+		ut, err := helpers.ConsumeUserToken(tx, token, models.UserTokenEmailVerification, models.UserTokenEmailChange)
+		if err != nil {
+			return err
+		}
+
+		var user models.User
+		if err := tx.First(&user, "id = ?", ut.UserID).Error; err != nil {
+			return err
+		}
+
+		// 2. Email change: the new address must still be free
+		if ut.Email != user.Email {
+			var count int64
+			if err := tx.Model(&models.User{}).Where("email = ? AND id <> ?", ut.Email, user.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return errEmailAlreadyTaken
+			}
+		}
+
+		// 3. Tandai email sebagai terverifikasi
+		return tx.Model(&user).Updates(map[string]any{
+			"email":             ut.Email,
+			"email_verified_at": time.Now(),
+		}).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, helpers.ErrUserTokenInvalid):
+			c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+				Success: false,
+				Message: err.Error(),
+			})
+		case errors.Is(err, errEmailAlreadyTaken):
+			c.JSON(http.StatusConflict, dto.BaseResponseError{
+				Success: false,
+				Message: err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+				Success: false,
+				Message: "Failed to verify email",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccess{
+		Success: true,
+		Message: "Email verified successfully",
+	})
+}
+
+// ResendVerificationEmail godoc
+// @Summary Resend verification email
+// @Description Sends a new verification link to the authenticated user's email address. Requires JWT token.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.BaseResponseSuccess
+// @Failure 400 {object} dto.BaseResponseError "Email already verified"
+// @Failure 401 {object} dto.BaseResponseError "Unauthorized"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /auth/verify-email/resend [post]
+func (u *UserController) ResendVerificationEmail(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	var user models.User
+	if err := u.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, dto.BaseResponseError{
+			Success: false,
+			Message: "Account not found",
+		})
+		return
+	}
+
+	if user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Email is already verified",
+		})
+		return
+	}
+
+	if err := sendEmailVerification(u.DB, user, user.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to send verification email",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccess{
+		Success: true,
+		Message: "Verification email sent",
+	})
+}
@@ -0,0 +1,307 @@
+package controllers
+
+import (
+	"log"
+	"mygram-api/database"
+	"mygram-api/dto"
+	"mygram-api/helpers"
+	"mygram-api/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FeedController menyimpan dependensi DB
+type FeedController struct {
+	DB     *gorm.DB
+	Logger *log.Logger
+}
+
+// NewFeedController adalah constructor yang menerima dependensi DB
+func NewFeedController(db *gorm.DB, appLogger *log.Logger) *FeedController {
+	return &FeedController{
+		DB:     db,
+		Logger: appLogger,
+	}
+}
+
+// GetFeed godoc
+// @Summary Home feed
+// @Description Photos of followed accounts and the caller's own, newest first. Keyset paginated: pass next_cursor as ?cursor= for the next page.
+// @Tags feed
+// @Produce json
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.FeedResponse}
+// @Failure 400 {object} dto.BaseResponseError
+// @Failure 500 {object} dto.BaseResponseError
+// @Security BearerAuth
+// @Router /feed [get]
+func (f *FeedController) GetFeed(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	// 1. Parse pagination
+	limit := parseLimitParam(c)
+	var cursor *helpers.FeedEntry
+	if raw := c.Query("cursor"); raw != "" {
+		entry, err := helpers.DecodeFeedCursor(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+				Success: false,
+				Message: "Invalid cursor",
+			})
+			return
+		}
+		cursor = &entry
+	}
+
+	// 2. Read the cached timeline; fetch one extra entry to know whether there is a next page
+	entries, ok, err := helpers.ReadFeedTimeline(userID, cursor, limit+1)
+	if err != nil {
+		f.Logger.Printf("Failed to read feed timeline of user %s: %v", userID, err)
+	}
+
+	// 3. Fallback ke query database
+	if !ok {
+		entries, err = f.queryFeed(userID, cursor, limit+1)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+				Success: false,
+				Message: "Failed to retrieve feed",
+			})
+			return
+		}
+		if cursor == nil && database.GetRedis() != nil {
+			go f.rebuildTimeline(userID)
+		}
+	}
+
+	hasMore := len(entries) > limit
+	if hasMore {
+		entries = entries[:limit]
+	}
+
+	// 4. Hydrate photos with owner, like and comment counts
+	ids := make([]uuid.UUID, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.PhotoID)
+	}
+	photos, err := loadPhotoCards(f.DB, ids, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve feed",
+		})
+		return
+	}
+
+	resp := dto.FeedResponse{Photos: photos, HasMore: hasMore}
+	if hasMore {
+		resp.NextCursor = entries[len(entries)-1].Cursor()
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Feed retrieved successfully",
+		Data:    resp,
+	})
+}
+
+// GetExplore godoc
+// @Summary Explore trending photos
+// @Description Recent photos of public profiles ranked by a time-decayed score of likes, comments, replies and views. The ranking is recomputed periodically; blocked and muted authors and the caller's own photos are excluded.
+// @Tags feed
+// @Produce json
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.ExploreResponse}
+// @Failure 500 {object} dto.BaseResponseError
+// @Security BearerAuth
+// @Router /explore [get]
+func (f *FeedController) GetExplore(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	page, limit := parsePageParams(c)
+
+	// 1. Ranking dari worker (Redis); tanpa Redis dihitung langsung dari database
+	ranking, ok, err := helpers.ReadExploreRanking()
+	if err != nil {
+		f.Logger.Printf("Failed to read explore ranking: %v", err)
+	}
+	if !ok {
+		ranking, err = helpers.RankExplorePhotos(f.DB, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+				Success: false,
+				Message: "Failed to retrieve explore",
+			})
+			return
+		}
+	}
+
+	// 2. Exclude own photos and blocked / muted authors
+	hidden, err := hiddenAuthorIDs(f.DB, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve explore",
+		})
+		return
+	}
+	hidden[userID] = true
+
+	offset := (page - 1) * limit
+	var ids []uuid.UUID
+	hasMore := false
+	for _, e := range ranking {
+		if hidden[e.UserID] {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		if len(ids) == limit {
+			hasMore = true
+			break
+		}
+		ids = append(ids, e.PhotoID)
+	}
+
+	// 3. Hydrate; profiles that turned private since the last ranking are dropped
+	photos, err := loadPhotoCards(f.DB, ids, func(ph models.Photo) bool {
+		return ph.User != nil && !ph.User.IsPrivate
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve explore",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Explore retrieved successfully",
+		Data: dto.ExploreResponse{
+			Photos:  photos,
+			Page:    page,
+			Limit:   limit,
+			HasMore: hasMore,
+		},
+	})
+}
+
+// queryFeed reads feed entries older than the cursor straight from the photos table
+func (f *FeedController) queryFeed(userID uuid.UUID, cursor *helpers.FeedEntry, limit int) ([]helpers.FeedEntry, error) {
+	following := f.DB.Model(&models.Follow{}).
+		Select("following_id").
+		Where("follower_id = ? AND status = ?", userID, models.FollowStatusAccepted)
+
+	query := f.DB.Model(&models.Photo{}).
+		Select("id", "created_at").
+		Where("(user_id IN (?) OR user_id = ?)", following, userID)
+	if cursor != nil {
+		query = query.Where("(created_at < ? OR (created_at = ? AND id < ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.PhotoID)
+	}
+
+	var rows []struct {
+		ID        uuid.UUID
+		CreatedAt time.Time
+	}
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	entries := make([]helpers.FeedEntry, 0, len(rows))
+	for _, r := range rows {
+		entries = append(entries, helpers.FeedEntry{PhotoID: r.ID, CreatedAt: r.CreatedAt})
+	}
+	return entries, nil
+}
+
+// rebuildTimeline caches the newest photos of the user's feed in Redis.
+// Concurrent misses of the same user start only one rebuild.
+func (f *FeedController) rebuildTimeline(userID uuid.UUID) {
+	generation, ok, err := helpers.BeginFeedRebuild(userID)
+	if err != nil {
+		f.Logger.Printf("Failed to rebuild feed timeline of user %s: %v", userID, err)
+		return
+	}
+	if !ok {
+		return
+	}
+	defer helpers.EndFeedRebuild(userID)
+
+	entries, err := f.queryFeed(userID, nil, helpers.FeedTimelineSize)
+	if err == nil {
+		err = helpers.StoreFeedTimeline(userID, generation, entries)
+	}
+	if err != nil {
+		f.Logger.Printf("Failed to rebuild feed timeline of user %s: %v", userID, err)
+	}
+}
+
+// loadPhotoCards loads the photos in the given order with owner, like and comment counts.
+// Photos deleted in the meantime, and photos rejected by keep (when set), are skipped.
+func loadPhotoCards(db *gorm.DB, ids []uuid.UUID, keep func(models.Photo) bool) ([]dto.FeedPhotoResponse, error) {
+	result := make([]dto.FeedPhotoResponse, 0, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var photos []models.Photo
+	if err := db.Preload("User").Where("id IN ?", ids).Find(&photos).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.Photo, len(photos))
+	for _, ph := range photos {
+		byID[ph.ID] = ph
+	}
+
+	var counts []struct {
+		PhotoID uuid.UUID
+		Count   int64
+	}
+	if err := db.Model(&models.Comment{}).
+		Select("photo_id, COUNT(*) AS count").
+		Where("photo_id IN ?", ids).
+		Group("photo_id").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	commentCounts := make(map[uuid.UUID]int64, len(counts))
+	for _, cc := range counts {
+		commentCounts[cc.PhotoID] = cc.Count
+	}
+
+	for _, id := range ids {
+		ph, found := byID[id]
+		if !found || (keep != nil && !keep(ph)) {
+			continue
+		}
+		item := dto.FeedPhotoResponse{
+			ID:           ph.ID.String(),
+			Title:        ph.Title,
+			Caption:      ph.Caption,
+			PhotoUrl:     ph.PhotoUrl,
+			Status:       ph.Status,
+			Variants:     toPhotoVariants(ph),
+			LikeCount:    ph.LikeCount,
+			CommentCount: commentCounts[ph.ID],
+			CreatedAt:    ph.CreatedAt,
+		}
+		if ph.User != nil {
+			item.Owner = toUserSummary(*ph.User)
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
@@ -0,0 +1,541 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"mygram-api/dto"
+	"mygram-api/helpers"
+	"mygram-api/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// toUserSummary maps a user to the short DTO used in lists
+func toUserSummary(user models.User) dto.UserSummaryResponse {
+	return dto.UserSummaryResponse{
+		ID:          user.ID.String(),
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		AvatarURL:   user.AvatarURL,
+	}
+}
+
+// loadRelationship reads the follow edges between the viewer and another user in both directions
+func loadRelationship(db *gorm.DB, viewerID, userID uuid.UUID) (dto.RelationshipResponse, error) {
+	var rel dto.RelationshipResponse
+
+	var follows []models.Follow
+	if err := db.Where("(follower_id = ? AND following_id = ?) OR (follower_id = ? AND following_id = ?)",
+		viewerID, userID, userID, viewerID).Find(&follows).Error; err != nil {
+		return rel, err
+	}
+
+	for _, f := range follows {
+		accepted := f.Status == models.FollowStatusAccepted
+		if f.FollowerID == viewerID {
+			rel.Following = accepted
+			rel.Requested = !accepted
+		} else {
+			rel.FollowedBy = accepted
+		}
+	}
+	rel.Mutual = rel.Following && rel.FollowedBy
+	return rel, nil
+}
+
+// canViewFollowGraph reports whether the viewer may see who the user follows / is followed by.
+// Private profiles only show it to their owner and accepted followers.
+func canViewFollowGraph(db *gorm.DB, viewerID uuid.UUID, user models.User) (bool, error) {
+	if !user.IsPrivate || viewerID == user.ID {
+		return true, nil
+	}
+	var count int64
+	err := db.Model(&models.Follow{}).
+		Where("follower_id = ? AND following_id = ? AND status = ?", viewerID, user.ID, models.FollowStatusAccepted).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// canViewUserContent reports whether the viewer may see the user's photos and what belongs to them
+// (comments, likes): never across a block, and for private profiles only as owner or accepted follower.
+func canViewUserContent(db *gorm.DB, viewerID uuid.UUID, owner models.User) (bool, error) {
+	if viewerID == owner.ID {
+		return true, nil
+	}
+	blocked, err := isBlockedBetween(db, viewerID, owner.ID)
+	if err != nil || blocked {
+		return false, err
+	}
+	return canViewFollowGraph(db, viewerID, owner)
+}
+
+// visibleContentSQL is canViewUserContent as a SQL condition on the owner column (%[1]s)
+const visibleContentSQL = `(%[1]s = @viewer OR (
+	NOT EXISTS (SELECT 1 FROM user_blocks WHERE user_blocks.kind = @block AND (
+		(user_blocks.user_id = @viewer AND user_blocks.target_id = %[1]s) OR
+		(user_blocks.user_id = %[1]s AND user_blocks.target_id = @viewer)))
+	AND (
+		EXISTS (SELECT 1 FROM users WHERE users.id = %[1]s AND users.is_private = @public) OR
+		EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = @viewer AND follows.following_id = %[1]s AND follows.status = @accepted))))`
+
+// visibleContentOf keeps the rows whose owner (e.g. "photos.user_id") the viewer may see, for lists
+func visibleContentOf(viewerID uuid.UUID, ownerColumn string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(fmt.Sprintf(visibleContentSQL, ownerColumn), map[string]any{
+			"viewer":   viewerID,
+			"block":    models.BlockKindBlock,
+			"public":   false,
+			"accepted": models.FollowStatusAccepted,
+		})
+	}
+}
+
+// requireContentVisible checks canViewUserContent for the owner of a photo and otherwise responds
+// 403 for a private profile or 404 (notFoundMessage) across a block. It reports whether to continue.
+func requireContentVisible(c *gin.Context, db *gorm.DB, viewerID, ownerID uuid.UUID, notFoundMessage string) bool {
+	var owner models.User
+	err := db.Select("id", "is_private").First(&owner, "id = ?", ownerID).Error
+	visible := false
+	if err == nil {
+		visible, err = canViewUserContent(db, viewerID, owner)
+	}
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to check visibility",
+		})
+	case visible:
+		return true
+	case owner.IsPrivate:
+		c.JSON(http.StatusForbidden, dto.BaseResponseError{
+			Success: false,
+			Message: "This profile is private",
+		})
+	default:
+		c.JSON(http.StatusNotFound, dto.BaseResponseError{
+			Success: false,
+			Message: notFoundMessage,
+		})
+	}
+	return false
+}
+
+// Follow godoc
+// @Summary Follow a user
+// @Description Follows a user. Following a private profile creates a pending follow request instead. Calling it again is a no-op. Requires JWT token.
+// @Tags follows
+// @Produce json
+// @Security BearerAuth
+// @Param userID path string true "User ID"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.FollowResponse}
+// @Failure 400 {object} dto.BaseResponseError "Invalid ID or following yourself"
+// @Failure 403 {object} dto.BaseResponseError "Blocked"
+// @Failure 404 {object} dto.BaseResponseError "User not found"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/{userID}/follow [post]
+func (u *UserController) Follow(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	followerID, _ := uuid.Parse(userIDStr)
+
+	targetID, err := uuid.Parse(c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+	if targetID == followerID {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "You cannot follow yourself",
+		})
+		return
+	}
+
+	var target models.User
+	if err := u.DB.Select("id", "is_private").First(&target, "id = ?", targetID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.BaseResponseError{
+				Success: false,
+				Message: "User not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to follow user",
+		})
+		return
+	}
+
+	blocked, err := isBlockedBetween(u.DB, followerID, targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to follow user",
+		})
+		return
+	}
+	if blocked {
+		c.JSON(http.StatusForbidden, dto.BaseResponseError{
+			Success: false,
+			Message: "You cannot follow this user",
+		})
+		return
+	}
+
+	// Profil private: buat follow request (pending) yang harus di-approve pemilik
+	follow := models.Follow{FollowerID: followerID, FollowingID: targetID, Status: models.FollowStatusAccepted}
+	if target.IsPrivate {
+		follow.Status = models.FollowStatusPending
+	} else {
+		now := time.Now()
+		follow.AcceptedAt = &now
+	}
+
+	// Idempotent: unique (follower, following) + ON CONFLICT DO NOTHING, lalu baca status yang tersimpan
+	var stored models.Follow
+	err = u.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&follow).Error
+	if err == nil {
+		err = u.DB.First(&stored, "follower_id = ? AND following_id = ?", followerID, targetID).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to follow user",
+		})
+		return
+	}
+
+	message := "User followed successfully"
+	if stored.Status == models.FollowStatusPending {
+		message = "Follow request sent"
+	} else if err := helpers.InvalidateFeed(followerID); err != nil {
+		u.Logger.Printf("Failed to invalidate feed of user %s: %v", followerID, err)
+	}
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: message,
+		Data: dto.FollowResponse{
+			FollowingID: targetID.String(),
+			Status:      stored.Status,
+		},
+	})
+}
+
+// Unfollow godoc
+// @Summary Unfollow a user
+// @Description Unfollows a user or cancels a pending follow request. Calling it again is a no-op. Requires JWT token.
+// @Tags follows
+// @Produce json
+// @Security BearerAuth
+// @Param userID path string true "User ID"
+// @Success 200 {object} dto.BaseResponseSuccess
+// @Failure 400 {object} dto.BaseResponseError "Invalid ID format"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/{userID}/follow [delete]
+func (u *UserController) Unfollow(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	followerID, _ := uuid.Parse(userIDStr)
+
+	targetID, err := uuid.Parse(c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	if err := u.DB.Where("follower_id = ? AND following_id = ?", followerID, targetID).Delete(&models.Follow{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to unfollow user",
+		})
+		return
+	}
+
+	if err := helpers.InvalidateFeed(followerID); err != nil {
+		u.Logger.Printf("Failed to invalidate feed of user %s: %v", followerID, err)
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccess{
+		Success: true,
+		Message: "User unfollowed successfully",
+	})
+}
+
+// ListFollowers godoc
+// @Summary List followers
+// @Description Lists the accepted followers of a user, newest first. Followers of a private profile are only visible to the owner and their followers. Requires JWT token.
+// @Tags follows
+// @Produce json
+// @Security BearerAuth
+// @Param username path string true "Username"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.FollowListResponse}
+// @Failure 403 {object} dto.BaseResponseError "Private profile"
+// @Failure 404 {object} dto.BaseResponseError "User not found"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/{username}/followers [get]
+func (u *UserController) ListFollowers(c *gin.Context) {
+	u.listFollows(c, "following_id", "Follower")
+}
+
+// ListFollowing godoc
+// @Summary List followed users
+// @Description Lists the users a user follows, newest first. For a private profile the list is only visible to the owner and their followers. Requires JWT token.
+// @Tags follows
+// @Produce json
+// @Security BearerAuth
+// @Param username path string true "Username"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.FollowListResponse}
+// @Failure 403 {object} dto.BaseResponseError "Private profile"
+// @Failure 404 {object} dto.BaseResponseError "User not found"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/{username}/following [get]
+func (u *UserController) ListFollowing(c *gin.Context) {
+	u.listFollows(c, "follower_id", "Following")
+}
+
+// listFollows lists the users on the other side (relation "Follower" or "Following") of the
+// accepted follows where ownerColumn is the user from the :username path parameter.
+func (u *UserController) listFollows(c *gin.Context, ownerColumn, relation string) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	viewerID, _ := uuid.Parse(userIDStr)
+
+	var user models.User
+	if err := u.DB.First(&user, "username = ?", c.Param("username")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.BaseResponseError{
+				Success: false,
+				Message: "User not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve users",
+		})
+		return
+	}
+
+	allowed, err := canViewFollowGraph(u.DB, viewerID, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve users",
+		})
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, dto.BaseResponseError{
+			Success: false,
+			Message: "This profile is private",
+		})
+		return
+	}
+
+	page, limit := parsePageParams(c)
+	query := u.DB.Model(&models.Follow{}).Where(ownerColumn+" = ? AND status = ?", user.ID, models.FollowStatusAccepted)
+
+	var total int64
+	var follows []models.Follow
+	err = query.Count(&total).Error
+	if err == nil {
+		err = query.Preload(relation).Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&follows).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve users",
+		})
+		return
+	}
+
+	respList := []dto.FollowUserResponse{}
+	for _, f := range follows {
+		other := f.Follower
+		if relation == "Following" {
+			other = f.Following
+		}
+		if other == nil {
+			continue
+		}
+		respList = append(respList, dto.FollowUserResponse{
+			UserSummaryResponse: toUserSummary(*other),
+			FollowedAt:          f.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Users retrieved successfully",
+		Data: dto.FollowListResponse{
+			Users: respList,
+			Page:  page,
+			Limit: limit,
+			Total: total,
+		},
+	})
+}
+
+// ListFollowRequests godoc
+// @Summary List pending follow requests
+// @Description Lists pending follow requests to the authenticated user's private profile. Requires JWT token.
+// @Tags follows
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=[]dto.FollowRequestResponse}
+// @Failure 401 {object} dto.BaseResponseError "Unauthorized"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/follow-requests [get]
+func (u *UserController) ListFollowRequests(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	var requests []models.Follow
+	if err := u.DB.Preload("Follower").
+		Where("following_id = ? AND status = ?", userID, models.FollowStatusPending).
+		Order("created_at DESC").Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve follow requests",
+		})
+		return
+	}
+
+	respList := []dto.FollowRequestResponse{}
+	for _, r := range requests {
+		if r.Follower == nil {
+			continue
+		}
+		respList = append(respList, dto.FollowRequestResponse{
+			ID:        r.ID.String(),
+			Follower:  toUserSummary(*r.Follower),
+			CreatedAt: r.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Follow requests retrieved successfully",
+		Data:    respList,
+	})
+}
+
+// ApproveFollowRequest godoc
+// @Summary Approve a follow request
+// @Description Approves a pending follow request to the authenticated user. Requires JWT token.
+// @Tags follows
+// @Produce json
+// @Security BearerAuth
+// @Param requestID path string true "Follow request ID"
+// @Success 200 {object} dto.BaseResponseSuccess
+// @Failure 400 {object} dto.BaseResponseError "Invalid ID format"
+// @Failure 404 {object} dto.BaseResponseError "Follow request not found"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/follow-requests/{requestID}/approve [post]
+func (u *UserController) ApproveFollowRequest(c *gin.Context) {
+	u.answerFollowRequest(c, true)
+}
+
+// RejectFollowRequest godoc
+// @Summary Reject a follow request
+// @Description Rejects (deletes) a pending follow request to the authenticated user. Requires JWT token.
+// @Tags follows
+// @Produce json
+// @Security BearerAuth
+// @Param requestID path string true "Follow request ID"
+// @Success 200 {object} dto.BaseResponseSuccess
+// @Failure 400 {object} dto.BaseResponseError "Invalid ID format"
+// @Failure 404 {object} dto.BaseResponseError "Follow request not found"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/follow-requests/{requestID} [delete]
+func (u *UserController) RejectFollowRequest(c *gin.Context) {
+	u.answerFollowRequest(c, false)
+}
+
+// answerFollowRequest approves or rejects a pending follow request addressed to the caller
+func (u *UserController) answerFollowRequest(c *gin.Context, approve bool) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	requestID, err := uuid.Parse(c.Param("requestID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid follow request ID",
+		})
+		return
+	}
+
+	// Hanya request pending yang ditujukan ke user sendiri
+	var request models.Follow
+	if err := u.DB.First(&request, "id = ? AND following_id = ? AND status = ?", requestID, userID, models.FollowStatusPending).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.BaseResponseError{
+				Success: false,
+				Message: "Follow request not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to update follow request",
+		})
+		return
+	}
+
+	query := u.DB.Model(&models.Follow{}).Where("id = ? AND status = ?", request.ID, models.FollowStatusPending)
+	var result *gorm.DB
+	if approve {
+		result = query.Updates(map[string]any{"status": models.FollowStatusAccepted, "accepted_at": time.Now()})
+	} else {
+		result = query.Delete(&models.Follow{})
+	}
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to update follow request",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, dto.BaseResponseError{
+			Success: false,
+			Message: "Follow request not found",
+		})
+		return
+	}
+
+	if approve {
+		if err := helpers.InvalidateFeed(request.FollowerID); err != nil {
+			u.Logger.Printf("Failed to invalidate feed of user %s: %v", request.FollowerID, err)
+		}
+	}
+
+	message := "Follow request rejected"
+	if approve {
+		message = "Follow request approved"
+	}
+	c.JSON(http.StatusOK, dto.BaseResponseSuccess{
+		Success: true,
+		Message: message,
+	})
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"fmt"
+	"mygram-api/dto"
+	"mygram-api/models"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxIncludedComments caps the comments (and replies per comment) expanded into a detail response
+const maxIncludedComments = maxPageLimit
+
+// firstRepliesPerComment scopes a Replies preload of the photo's comments to the oldest maxIncludedComments
+// replies of each comment. A plain Limit would apply to the replies of all comments together.
+func firstRepliesPerComment(photoID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		ranked := db.Session(&gorm.Session{NewDB: true}).Model(&models.Comment{}).
+			Select("id, ROW_NUMBER() OVER (PARTITION BY parent_comment_id ORDER BY created_at, id) AS reply_rank").
+			Where("photo_id = ? AND parent_comment_id IS NOT NULL", photoID)
+		firstIDs := db.Session(&gorm.Session{NewDB: true}).Table("(?) AS ranked", ranked).
+			Select("id").
+			Where("reply_rank <= ?", maxIncludedComments)
+		return db.Where("id IN (?)", firstIDs).Order("created_at ASC").Order("id ASC")
+	}
+}
+
+// parseIncludes reads ?include=a,b and checks every value against allowed; it writes a 400 response
+// and returns false for unknown values
+func parseIncludes(c *gin.Context, allowed ...string) (map[string]bool, bool) {
+	includes := map[string]bool{}
+	for _, name := range strings.Split(c.Query("include"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(allowed, name) {
+			c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+				Success: false,
+				Message: fmt.Sprintf("Invalid include %q, allowed: %s", name, strings.Join(allowed, ", ")),
+			})
+			return nil, false
+		}
+		includes[name] = true
+	}
+	return includes, true
+}
@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mygram-api/dto"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Shared query layer for list endpoints: ?limit=, ?cursor= (keyset), ?sort= on whitelisted fields
+// ("created_at" ascending, "-created_at" descending) and per-endpoint filters.
+
+const invalidListCursor = "Invalid cursor"
+
+type sortKind int
+
+const (
+	sortTime sortKind = iota
+	sortString
+	sortInt
+)
+
+// listSort is a sortable field; value reads the field from a row for the next cursor
+type listSort[T any] struct {
+	column string
+	kind   sortKind
+	value  func(T) any
+}
+
+type filterKind int
+
+const (
+	filterUUID filterKind = iota
+	filterAfter
+	filterBefore
+)
+
+// listFilter is a query parameter filtering on column
+type listFilter struct {
+	column string
+	kind   filterKind
+}
+
+// listQuery describes the sorts and filters an endpoint accepts. With presets, ?sort= only accepts
+// the preset names (e.g. "newest" -> "-created_at") instead of the field names.
+type listQuery[T any] struct {
+	sorts       map[string]listSort[T]
+	presets     map[string]string
+	defaultSort string
+	filters     map[string]listFilter
+	idColumn    string // tie-breaker column, default "id"
+	id          func(T) uuid.UUID
+}
+
+// listCursor is the opaque cursor: the sort it was created for, the sort value and id of the last row
+type listCursor struct {
+	Sort  string          `json:"s"`
+	Value json.RawMessage `json:"v"`
+	ID    uuid.UUID       `json:"id"`
+}
+
+// find applies the request's filters, sort and cursor to query and returns at most limit rows.
+// Invalid parameters are returned as listParamError so respondListError can answer 400.
+func (q listQuery[T]) find(c *gin.Context, query *gorm.DB) ([]T, *dto.ListMeta, error) {
+	sortParam := c.DefaultQuery("sort", q.defaultSort)
+	if q.presets != nil {
+		preset, ok := q.presets[sortParam]
+		if !ok {
+			return nil, nil, listParamErrorf("Invalid sort, allowed: %s", strings.Join(q.sortNames(), ", "))
+		}
+		sortParam = preset
+	}
+	desc := strings.HasPrefix(sortParam, "-")
+	field, ok := q.sorts[strings.TrimPrefix(sortParam, "-")]
+	if !ok {
+		return nil, nil, listParamErrorf("Invalid sort, allowed: %s", strings.Join(q.sortNames(), ", "))
+	}
+
+	for name, filter := range q.filters {
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		switch filter.kind {
+		case filterUUID:
+			id, err := uuid.Parse(raw)
+			if err != nil {
+				return nil, nil, listParamErrorf("Invalid %s", name)
+			}
+			query = query.Where(filter.column+" = ?", id)
+		case filterAfter, filterBefore:
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return nil, nil, listParamErrorf("Invalid %s, expected RFC 3339 timestamp", name)
+			}
+			op := " > ?"
+			if filter.kind == filterBefore {
+				op = " < ?"
+			}
+			// Zona server, sama dengan timestamp yang ditulis aplikasi (SQLite membandingkan sebagai teks)
+			query = query.Where(filter.column+op, t.Local())
+		}
+	}
+
+	// Keyset: baris setelah cursor menurut (kolom sort, id), id sebagai tie-breaker
+	op, dir := ">", "ASC"
+	if desc {
+		op, dir = "<", "DESC"
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		value, id, err := q.decodeCursor(raw, sortParam, field.kind)
+		if err != nil {
+			return nil, nil, err
+		}
+		query = query.Where(
+			fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND %[3]s %[2]s ?))", field.column, op, q.tieBreaker()),
+			value, value, id,
+		)
+	}
+
+	limit := parseLimitParam(c)
+	var rows []T
+	if err := query.
+		Order(field.column + " " + dir).
+		Order(q.tieBreaker() + " " + dir).
+		Limit(limit + 1).
+		Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	meta := &dto.ListMeta{Limit: limit}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		meta.HasMore = true
+		last := rows[len(rows)-1]
+		meta.NextCursor = encodeListCursor(sortParam, field.value(last), q.id(last))
+	}
+	return rows, meta, nil
+}
+
+func (q listQuery[T]) tieBreaker() string {
+	if q.idColumn != "" {
+		return q.idColumn
+	}
+	return "id"
+}
+
+func (q listQuery[T]) sortNames() []string {
+	names := make([]string, 0, len(q.sorts))
+	for name := range q.sorts {
+		names = append(names, name)
+	}
+	if q.presets != nil {
+		names = names[:0]
+		for name := range q.presets {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func encodeListCursor(sortParam string, value any, id uuid.UUID) string {
+	if t, ok := value.(time.Time); ok {
+		value = t.Format(time.RFC3339Nano)
+	}
+	v, _ := json.Marshal(value)
+	raw, _ := json.Marshal(listCursor{Sort: sortParam, Value: v, ID: id})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor parses a cursor created for the same sort and converts its value for the query
+func (q listQuery[T]) decodeCursor(raw, sortParam string, kind sortKind) (any, uuid.UUID, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, uuid.Nil, listParamErrorf(invalidListCursor)
+	}
+	var cur listCursor
+	if err := json.Unmarshal(data, &cur); err != nil || cur.ID == uuid.Nil {
+		return nil, uuid.Nil, listParamErrorf(invalidListCursor)
+	}
+	if cur.Sort != sortParam {
+		return nil, uuid.Nil, listParamErrorf("Cursor does not match sort")
+	}
+
+	switch kind {
+	case sortTime:
+		var s string
+		if err := json.Unmarshal(cur.Value, &s); err == nil {
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				return t, cur.ID, nil
+			}
+		}
+	case sortString:
+		var s string
+		if err := json.Unmarshal(cur.Value, &s); err == nil {
+			return s, cur.ID, nil
+		}
+	case sortInt:
+		var n int64
+		if err := json.Unmarshal(cur.Value, &n); err == nil {
+			return n, cur.ID, nil
+		}
+	}
+	return nil, uuid.Nil, listParamErrorf(invalidListCursor)
+}
+
+// listParamError is a client error in the list parameters
+type listParamError struct{ msg string }
+
+func (e listParamError) Error() string { return e.msg }
+
+func listParamErrorf(format string, args ...any) error {
+	return listParamError{msg: fmt.Sprintf(format, args...)}
+}
+
+// respondListError answers 400 for invalid list parameters and 500 with fallback otherwise
+func respondListError(c *gin.Context, err error, fallback string) {
+	var perr listParamError
+	if errors.As(err, &perr) {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: perr.msg,
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+		Success: false,
+		Message: fallback,
+	})
+}
@@ -0,0 +1,458 @@
+package controllers
+
+import (
+	"mygram-api/dto"
+	"mygram-api/helpers"
+	"mygram-api/models"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// mfaPendingTokenTTL is how long the user has to enter the second factor after the password
+	mfaPendingTokenTTL = 5 * time.Minute
+	// mfaRecoveryCodeCount is the number of recovery codes generated when 2FA is confirmed
+	mfaRecoveryCodeCount = 10
+)
+
+// mfaIssuer returns the issuer shown in authenticator apps (MFA_ISSUER, default "MyGram")
+func mfaIssuer() string {
+	if issuer := os.Getenv("MFA_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "MyGram"
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code.
+// Accepted TOTP steps and recovery codes cannot be used again.
+func verifySecondFactor(db *gorm.DB, user models.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	// 1. TOTP code (replay protection: the time step must be newer than the last accepted one)
+	if step, ok := helpers.ValidateTOTP(user.TOTPSecret, code, time.Now()); ok {
+		res := db.Model(&models.User{}).
+			Where("id = ? AND totp_last_step < ?", user.ID, step).
+			Update("totp_last_step", step)
+		if res.Error != nil {
+			return false, res.Error
+		}
+		return res.RowsAffected == 1, nil
+	}
+
+	// 2. Recovery code
+	var codes []models.MFARecoveryCode
+	if err := db.Where("user_id = ? AND used_at IS NULL", user.ID).Find(&codes).Error; err != nil {
+		return false, err
+	}
+	for _, rc := range codes {
+		if helpers.CheckPasswordHash(strings.ToLower(code), rc.CodeHash) {
+			res := db.Model(&models.MFARecoveryCode{}).
+				Where("id = ? AND used_at IS NULL", rc.ID).
+				Update("used_at", time.Now())
+			if res.Error != nil {
+				return false, res.Error
+			}
+			return res.RowsAffected == 1, nil
+		}
+	}
+	return false, nil
+}
+
+// EnrollMFA godoc
+// @Summary Start 2FA enrollment
+// @Description Generates a new TOTP secret and returns it with an otpauth:// URI for authenticator apps. 2FA is enabled only after confirming a first code. Requires JWT token.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.MFAEnrollResponse}
+// @Failure 400 {object} dto.BaseResponseError "2FA already enabled"
+// @Failure 401 {object} dto.BaseResponseError "Unauthorized"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/mfa/enroll [post]
+func (u *UserController) EnrollMFA(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	var user models.User
+	if err := u.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, dto.BaseResponseError{
+			Success: false,
+			Message: "Account not found",
+		})
+		return
+	}
+
+	if user.TOTPEnabledAt != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Two-factor authentication is already enabled",
+		})
+		return
+	}
+
+	// Simpan secret (belum aktif sampai dikonfirmasi)
+	secret, err := helpers.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to generate secret",
+		})
+		return
+	}
+	if err := u.DB.Model(&user).Updates(map[string]any{"totp_secret": secret, "totp_last_step": 0}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to start two-factor enrollment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Scan the QR code and confirm with a code from your authenticator app",
+		Data: dto.MFAEnrollResponse{
+			Secret:     secret,
+			OtpauthURI: helpers.TOTPProvisioningURI(mfaIssuer(), user.Email, secret),
+		},
+	})
+}
+
+// ConfirmMFA godoc
+// @Summary Confirm 2FA enrollment
+// @Description Enables 2FA after checking a first TOTP code and returns one-time recovery codes. The recovery codes are shown only once. Requires JWT token.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body dto.MFACodeRequest true "TOTP code"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.MFAConfirmResponse}
+// @Failure 400 {object} dto.BaseResponseError "Invalid code or enrollment not started"
+// @Failure 401 {object} dto.BaseResponseError "Unauthorized"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/mfa/confirm [post]
+func (u *UserController) ConfirmMFA(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	var req dto.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	var user models.User
+	if err := u.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, dto.BaseResponseError{
+			Success: false,
+			Message: "Account not found",
+		})
+		return
+	}
+
+	if user.TOTPEnabledAt != nil || user.TOTPSecret == "" {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Two-factor enrollment has not been started",
+		})
+		return
+	}
+
+	// 1. Cek kode pertama dari authenticator app
+	step, ok := helpers.ValidateTOTP(user.TOTPSecret, strings.TrimSpace(req.Code), time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid two-factor code",
+		})
+		return
+	}
+
+	// 2. Buat recovery codes (hanya hash yang disimpan)
+	codes, err := helpers.GenerateRecoveryCodes(mfaRecoveryCodeCount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to generate recovery codes",
+		})
+		return
+	}
+
+	// 3. Aktifkan 2FA
+	err = u.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		for _, code := range codes {
+			hash, err := helpers.HashPassword(code)
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(&models.MFARecoveryCode{UserID: user.ID, CodeHash: hash}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&user).Updates(map[string]any{
+			"totp_enabled_at": time.Now(),
+			"totp_last_step":  step,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to enable two-factor authentication",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Two-factor authentication enabled. Store these recovery codes in a safe place",
+		Data:    dto.MFAConfirmResponse{RecoveryCodes: codes},
+	})
+}
+
+// DisableMFA godoc
+// @Summary Disable 2FA
+// @Description Turns off 2FA after checking the password and a TOTP or recovery code. Requires JWT token.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body dto.MFADisableRequest true "Password and second factor"
+// @Success 200 {object} dto.BaseResponseSuccess
+// @Failure 400 {object} dto.BaseResponseError "2FA not enabled or invalid code"
+// @Failure 401 {object} dto.BaseResponseError "Unauthorized or wrong password"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/mfa [delete]
+func (u *UserController) DisableMFA(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	var req dto.MFADisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	var user models.User
+	if err := u.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, dto.BaseResponseError{
+			Success: false,
+			Message: "Account not found",
+		})
+		return
+	}
+
+	if user.TOTPEnabledAt == nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Two-factor authentication is not enabled",
+		})
+		return
+	}
+
+	if !helpers.CheckPasswordHash(req.Password, user.Password) {
+		c.JSON(http.StatusUnauthorized, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid password",
+		})
+		return
+	}
+
+	ok, err := verifySecondFactor(u.DB, user, req.Code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to verify two-factor code",
+		})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid two-factor code",
+		})
+		return
+	}
+
+	err = u.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&user).Updates(map[string]any{
+			"totp_secret":     "",
+			"totp_enabled_at": nil,
+			"totp_last_step":  0,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to disable two-factor authentication",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccess{
+		Success: true,
+		Message: "Two-factor authentication disabled",
+	})
+}
+
+// VerifyMFA godoc
+// @Summary Complete login with 2FA
+// @Description Exchanges the mfa_pending token from /auth/login and a TOTP or recovery code for the real token pair. The mfa_pending token completes only one login and allows MFA_MAX_ATTEMPTS codes (default 5); after that the user has to log in again.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body dto.MFAVerifyRequest true "mfa_pending token and second factor"
+// @Success 200 {object} dto.UserLoginResponse "Successfully logged in"
+// @Failure 400 {object} dto.BaseResponseError "Invalid request body"
+// @Failure 401 {object} dto.BaseResponseError "Invalid token or code"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /auth/mfa/verify [post]
+func (u *UserController) VerifyMFA(c *gin.Context) {
+	var req dto.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// 1. Validasi token mfa_pending
+	claims, err := helpers.VerifyToken(req.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, dto.BaseResponseError{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+	revoked, err := helpers.IsTokenRevoked(claims)
+	if typ, _ := claims["typ"].(string); typ != helpers.TokenTypeMFAPending || revoked || err != nil {
+		c.JSON(http.StatusUnauthorized, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid MFA token",
+		})
+		return
+	}
+
+	userIDStr, _ := claims["id"].(string)
+	var user models.User
+	if err := u.DB.First(&user, "id = ?", userIDStr).Error; err != nil || user.TOTPEnabledAt == nil {
+		c.JSON(http.StatusUnauthorized, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid MFA token",
+		})
+		return
+	}
+
+	// 2. Pakai satu percobaan dari challenge token ini (conditional update, aman untuk request paralel)
+	jti, _ := claims["jti"].(string)
+	maxAttempts := helpers.MFAMaxAttempts()
+	res := u.DB.Model(&models.MFAChallenge{}).
+		Where("id = ? AND user_id = ? AND used_at IS NULL AND attempts < ? AND expires_at > ?", jti, user.ID, maxAttempts, time.Now()).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to verify two-factor code",
+		})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusUnauthorized, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid MFA token",
+		})
+		return
+	}
+
+	// 3. Cek TOTP / recovery code
+	ok, err := verifySecondFactor(u.DB, user, req.Code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to verify two-factor code",
+		})
+		return
+	}
+	if !ok {
+		var challenge models.MFAChallenge
+		if err := u.DB.Select("attempts").First(&challenge, "id = ?", jti).Error; err == nil && int64(challenge.Attempts) >= maxAttempts {
+			u.revokeMFAToken(claims)
+			c.JSON(http.StatusUnauthorized, dto.BaseResponseError{
+				Success: false,
+				Message: "Too many invalid two-factor codes, please log in again",
+			})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid two-factor code",
+		})
+		return
+	}
+
+	// 4. Token mfa_pending hanya untuk satu login
+	res = u.DB.Model(&models.MFAChallenge{}).
+		Where("id = ? AND used_at IS NULL", jti).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to verify two-factor code",
+		})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusUnauthorized, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid MFA token",
+		})
+		return
+	}
+	u.revokeMFAToken(claims)
+
+	// 5. Login selesai: buat token pair
+	response, err := issueLoginTokens(c, u.DB, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to generate token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// revokeMFAToken puts a finished mfa_pending token on the access token denylist
+func (u *UserController) revokeMFAToken(claims jwt.MapClaims) {
+	jti, _ := claims["jti"].(string)
+	exp, err := claims.GetExpirationTime()
+	if jti == "" || err != nil || exp == nil {
+		return
+	}
+	if err := helpers.RevokeToken(jti, exp.Time); err != nil {
+		u.Logger.Printf("Failed to revoke MFA token %s: %v", jti, err)
+	}
+}
@@ -0,0 +1,312 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"mygram-api/dto"
+	"mygram-api/helpers"
+	"mygram-api/models"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// oidcLoginStateTTL is how long the user has to finish signing in at the provider
+const oidcLoginStateTTL = 10 * time.Minute
+
+var (
+	errOIDCEmailMissing     = errors.New("the identity provider did not return an email address")
+	errOIDCEmailNotVerified = errors.New("an account with this email already exists; sign in with your password to use it")
+	usernameInvalidChars    = regexp.MustCompile(`[^a-z0-9_]+`)
+)
+
+// OIDCController menyimpan dependensi DB dan daftar identity provider
+type OIDCController struct {
+	DB        *gorm.DB
+	Logger    *log.Logger
+	Providers map[string]*helpers.OIDCProvider
+}
+
+// NewOIDCController adalah constructor yang menerima dependensi DB dan provider
+func NewOIDCController(db *gorm.DB, appLogger *log.Logger, providers map[string]*helpers.OIDCProvider) *OIDCController {
+	return &OIDCController{
+		DB:        db,
+		Logger:    appLogger,
+		Providers: providers,
+	}
+}
+
+// Login godoc
+// @Summary Start sign in with an external provider
+// @Description Returns the provider authorization URL (authorization code flow with PKCE). Open it in a browser; the provider redirects back to the callback endpoint.
+// @Tags users
+// @Produce json
+// @Param provider path string true "Provider name, e.g. google"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.OIDCLoginResponse}
+// @Failure 404 {object} dto.BaseResponseError "Unknown provider"
+// @Failure 502 {object} dto.BaseResponseError "Provider unavailable"
+// @Router /auth/oidc/{provider}/login [get]
+func (o *OIDCController) Login(c *gin.Context) {
+	provider, ok := o.Providers[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, dto.BaseResponseError{
+			Success: false,
+			Message: helpers.ErrOIDCProviderNotFound.Error(),
+		})
+		return
+	}
+
+	// 1. Generate state, nonce dan PKCE code verifier
+	state, err1 := helpers.GenerateOpaqueToken()
+	nonce, err2 := helpers.GenerateOpaqueToken()
+	verifier, err3 := helpers.GenerateOpaqueToken()
+	if err := errors.Join(err1, err2, err3); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to start sign in",
+		})
+		return
+	}
+
+	// 2. Simpan di server, browser hanya membawa state (state yang kedaluwarsa sekalian dihapus)
+	if err := o.DB.Where("expires_at < ?", time.Now()).Delete(&models.OIDCLoginState{}).Error; err != nil {
+		o.Logger.Printf("Failed to delete expired OIDC login states: %v", err)
+	}
+	loginState := models.OIDCLoginState{
+		StateHash:    helpers.HashOpaqueToken(state),
+		Provider:     provider.Name,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(oidcLoginStateTTL),
+	}
+	if err := o.DB.Create(&loginState).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to start sign in",
+		})
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), state, nonce, verifier)
+	if err != nil {
+		o.Logger.Printf("OIDC provider %s unavailable: %v", provider.Name, err)
+		c.JSON(http.StatusBadGateway, dto.BaseResponseError{
+			Success: false,
+			Message: "Identity provider is unavailable",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Continue signing in at the identity provider",
+		Data:    dto.OIDCLoginResponse{AuthorizationURL: authURL},
+	})
+}
+
+// Callback godoc
+// @Summary Finish sign in with an external provider
+// @Description Validates the state, exchanges the authorization code, verifies the ID token against the provider JWKS and logs the user in. Unknown users are created. An existing user is linked only when both the provider and MyGram have verified the email.
+// @Tags users
+// @Produce json
+// @Param provider path string true "Provider name, e.g. google"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State returned by the provider"
+// @Success 200 {object} dto.UserLoginResponse "Successfully logged in"
+// @Failure 400 {object} dto.BaseResponseError "Invalid or expired state, or provider error"
+// @Failure 401 {object} dto.BaseResponseError "Invalid ID token"
+// @Failure 404 {object} dto.BaseResponseError "Unknown provider"
+// @Failure 409 {object} dto.BaseResponseError "Email already used by an unlinked account"
+// @Router /auth/oidc/{provider}/callback [get]
+func (o *OIDCController) Callback(c *gin.Context) {
+	provider, ok := o.Providers[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, dto.BaseResponseError{
+			Success: false,
+			Message: helpers.ErrOIDCProviderNotFound.Error(),
+		})
+		return
+	}
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Sign in was not completed: " + providerErr,
+		})
+		return
+	}
+
+	// 1. Ambil dan hapus state (sekali pakai)
+	var loginState models.OIDCLoginState
+	err := o.DB.Where("state_hash = ? AND provider = ?", helpers.HashOpaqueToken(c.Query("state")), provider.Name).
+		First(&loginState).Error
+	if err == nil {
+		res := o.DB.Delete(&models.OIDCLoginState{}, "id = ?", loginState.ID)
+		if res.Error != nil || res.RowsAffected == 0 {
+			err = gorm.ErrRecordNotFound
+		}
+	}
+	if err != nil || time.Now().After(loginState.ExpiresAt) {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid or expired sign in state",
+		})
+		return
+	}
+
+	// 2. Tukar authorization code dengan ID token dan validasi
+	claims, err := provider.Exchange(c.Request.Context(), c.Query("code"), loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		o.Logger.Printf("OIDC sign in with %s failed: %v", provider.Name, err)
+		c.JSON(http.StatusUnauthorized, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to sign in with " + provider.Name,
+		})
+		return
+	}
+
+	// 3. Cari / buat / tautkan user
+	user, err := o.findOrCreateUser(provider.Name, claims)
+	if err != nil {
+		switch {
+		case errors.Is(err, errOIDCEmailNotVerified):
+			c.JSON(http.StatusConflict, dto.BaseResponseError{
+				Success: false,
+				Message: err.Error(),
+			})
+		case errors.Is(err, errOIDCEmailMissing):
+			c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+				Success: false,
+				Message: err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+				Success: false,
+				Message: "Failed to sign in",
+			})
+		}
+		return
+	}
+
+	// 4. Generate token, sama seperti login dengan password
+	completeLogin(c, o.DB, user)
+}
+
+// findOrCreateUser returns the user linked to the provider subject. Otherwise an existing user is
+// linked when both the provider and the local account have verified the email, or a new user is created.
+func (o *OIDCController) findOrCreateUser(providerName string, claims *helpers.OIDCIDTokenClaims) (models.User, error) {
+	var user models.User
+	created := false
+
+	err := o.DB.Transaction(func(tx *gorm.DB) error {
+		// 1. Identity sudah tertaut
+		var identity models.UserIdentity
+		err := tx.Where("provider = ? AND subject = ?", providerName, claims.Subject).First(&identity).Error
+		if err == nil {
+			return tx.First(&user, "id = ?", identity.UserID).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if claims.Email == "" {
+			return errOIDCEmailMissing
+		}
+
+		// 2. User dengan email yang sama: tautkan hanya jika email terverifikasi oleh provider dan oleh kita.
+		// Akun lokal yang belum terverifikasi bisa saja didaftarkan orang lain dengan email korban.
+		err = tx.Where("email = ?", claims.Email).First(&user).Error
+		switch {
+		case err == nil:
+			if !claims.EmailVerified || user.EmailVerifiedAt == nil {
+				return errOIDCEmailNotVerified
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			// 3. User baru
+			if user, err = newOIDCUser(tx, claims); err != nil {
+				return err
+			}
+			created = true
+		default:
+			return err
+		}
+
+		return tx.Create(&models.UserIdentity{
+			UserID:   user.ID,
+			Provider: providerName,
+			Subject:  claims.Subject,
+			Email:    claims.Email,
+		}).Error
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if created {
+		helpers.SendWelcomeEmail(user.Email, user.Username)
+	}
+	return user, nil
+}
+
+// newOIDCUser creates a user for a first sign in with an external provider.
+// The account gets a random password; the user can set one later with the password reset flow.
+func newOIDCUser(tx *gorm.DB, claims *helpers.OIDCIDTokenClaims) (models.User, error) {
+	randomPassword, err := helpers.GenerateOpaqueToken()
+	if err != nil {
+		return models.User{}, err
+	}
+	hashPassword, err := helpers.HashPassword(randomPassword)
+	if err != nil {
+		return models.User{}, err
+	}
+	username, err := availableUsername(tx, strings.Split(claims.Email, "@")[0])
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user := models.User{
+		Username: username,
+		Email:    claims.Email,
+		Password: hashPassword,
+	}
+	if claims.EmailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// availableUsername derives a free username from base, adding a random suffix when taken.
+func availableUsername(tx *gorm.DB, base string) (string, error) {
+	base = usernameInvalidChars.ReplaceAllString(strings.ToLower(base), "_")
+	if base == "" {
+		base = "user"
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+
+		suffix := make([]byte, 3)
+		if _, err := rand.Read(suffix); err != nil {
+			return "", err
+		}
+		candidate = base + "_" + hex.EncodeToString(suffix)
+	}
+	return "", errors.New("could not find an available username")
+}
@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"errors"
+	"mygram-api/dto"
+	"mygram-api/helpers"
+	"mygram-api/models"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// passwordResetTokenTTL returns how long a reset link stays valid (PASSWORD_RESET_TOKEN_TTL, default 1 hour)
+func passwordResetTokenTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("PASSWORD_RESET_TOKEN_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return time.Hour
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset link
+// @Description Sends a single-use password reset link to the email address. The response is the same whether or not the email is registered.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body dto.ForgotPasswordRequest true "Account email"
+// @Success 200 {object} dto.BaseResponseSuccess
+// @Failure 400 {object} dto.BaseResponseError "Invalid request body or validation error"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /auth/password/forgot [post]
+func (u *UserController) ForgotPassword(c *gin.Context) {
+	var req dto.ForgotPasswordRequest
+
+	// 1. Binding Request Body
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Same response for known and unknown emails, so the endpoint doesn't reveal accounts
+	response := dto.BaseResponseSuccess{
+		Success: true,
+		Message: "If the email is registered, a password reset link has been sent",
+	}
+
+	// 2. Cari user berdasarkan email
+	var user models.User
+	if err := u.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusOK, response)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to process password reset",
+		})
+		return
+	}
+
+	// 3. Buat token sekali pakai
+	token, err := helpers.CreateUserToken(u.DB, user.ID, models.UserTokenPasswordReset, passwordResetTokenTTL())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to process password reset",
+		})
+		return
+	}
+
+	// 4. Kirim email (Non-blocking via Goroutine)
+	resetURL := helpers.BuildActionURL("PASSWORD_RESET_URL", "/reset-password", token)
+	helpers.SendPasswordResetEmail(user.Email, user.Username, resetURL)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetPassword godoc
+// @Summary Reset password with a token
+// @Description Consumes a password reset token, sets the new password and logs the user out of every session.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body dto.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} dto.BaseResponseSuccess
+// @Failure 400 {object} dto.BaseResponseError "Invalid request body, invalid or expired token"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /auth/password/reset [post]
+func (u *UserController) ResetPassword(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+
+	// 1. Binding Request Body
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	hashPassword, err := helpers.HashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to hash password",
+		})
+		return
+	}
+
+	// 2. Consume token dan update password dalam satu transaksi
+	var resetToken *models.UserToken
+	err = u.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		resetToken, err = helpers.ConsumeUserToken(tx, req.Token, models.UserTokenPasswordReset)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ?", resetToken.UserID).Update("password", hashPassword).Error
+	})
+	if err != nil {
+		if errors.Is(err, helpers.ErrUserTokenInvalid) {
+			c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to reset password",
+		})
+		return
+	}
+
+	// 3. Invalidate existing sessions
+	if err := helpers.RevokeUserSessions(u.DB, resetToken.UserID); err != nil {
+		u.Logger.Printf("Failed to revoke sessions of user %s after password reset: %v", resetToken.UserID, err)
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccess{
+		Success: true,
+		Message: "Password has been reset successfully",
+	})
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Changes the authenticated user's password. Other sessions are logged out and a new access token for the current session is returned. Requires JWT token.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body dto.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.ChangePasswordResponse}
+// @Failure 400 {object} dto.BaseResponseError "Invalid request body or validation error"
+// @Failure 401 {object} dto.BaseResponseError "Current password is incorrect"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/password [put]
+func (u *UserController) ChangePassword(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+	sid, _ := userData["sid"].(string)
+	sessionID, _ := uuid.Parse(sid) // uuid.Nil for tokens without session, then every session is revoked
+
+	var req dto.ChangePasswordRequest
+
+	// 1. Binding dan Validasi Request Body (aturan password sama dengan register)
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// 2. Cek password saat ini
+	var user models.User
+	if err := u.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, dto.BaseResponseError{
+			Success: false,
+			Message: "Account not found",
+		})
+		return
+	}
+	if !helpers.CheckPasswordHash(req.CurrentPassword, user.Password) {
+		c.JSON(http.StatusUnauthorized, dto.BaseResponseError{
+			Success: false,
+			Message: "Current password is incorrect",
+		})
+		return
+	}
+
+	// 3. Simpan password baru
+	hashPassword, err := helpers.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to hash password",
+		})
+		return
+	}
+	if err := u.DB.Model(&user).Update("password", hashPassword).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to change password",
+		})
+		return
+	}
+
+	// 4. Logout sesi lain, lalu buat access token baru untuk sesi ini
+	if err := helpers.RevokeOtherUserSessions(u.DB, user.ID, sessionID); err != nil {
+		u.Logger.Printf("Failed to revoke other sessions of user %s after password change: %v", user.ID, err)
+	}
+	token, err := helpers.CreateToken(user.ID, user.Email, helpers.WithSessionID(sessionID), helpers.WithRole(user.Role))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to generate token",
+		})
+		return
+	}
+
+	// 5. Kirim notifikasi (Non-blocking via Goroutine)
+	helpers.SendPasswordChangedEmail(user.Email, user.Username)
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Password changed successfully",
+		Data: dto.ChangePasswordResponse{
+			Token:     token,
+			TokenType: "Bearer",
+			ExpiresIn: int64(helpers.AccessTokenTTL().Seconds()),
+		},
+	})
+}
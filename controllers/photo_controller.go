@@ -1,9 +1,12 @@
 package controllers
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
 	"mygram-api/dto"
+	"mygram-api/helpers"
 	"mygram-api/models"
 	"net/http"
 
@@ -29,15 +32,53 @@ func NewPhotoController(db *gorm.DB, appLogger *log.Logger) *PhotoController {
 // Using Photo DTOs from dto/photoDto.go
 // PhotoCreateRequest, PhotoUpdateRequest and PhotoResponse are defined in the dto package
 
+// toPhotoVariants returns the variant URLs of a processed upload, nil for photos without variants
+func toPhotoVariants(photo models.Photo) *dto.PhotoVariantsResponse {
+	if photo.ThumbnailURL == "" {
+		return nil
+	}
+	return &dto.PhotoVariantsResponse{
+		Thumbnail: photo.ThumbnailURL,
+		Medium:    photo.MediumURL,
+		Full:      photo.PhotoUrl,
+	}
+}
+
+// toPhotoResponse maps a photo to its response; likedByMe is whether the caller liked it
+func toPhotoResponse(photo models.Photo, likedByMe bool) dto.PhotoResponse {
+	return dto.PhotoResponse{
+		ID:        photo.ID.String(),
+		Title:     photo.Title,
+		Caption:   photo.Caption,
+		PhotoUrl:  photo.PhotoUrl,
+		UserID:    photo.UserID.String(),
+		Status:    photo.Status,
+		Width:     photo.Width,
+		Height:    photo.Height,
+		Variants:  toPhotoVariants(photo),
+		LikeCount: photo.LikeCount,
+		LikedByMe: likedByMe,
+		CreatedAt: photo.CreatedAt,
+		UpdatedAt: photo.UpdatedAt,
+	}
+}
+
 // Create godoc
 // @Summary Create a new photo
-// @Description Create a new photo for authenticated user
+// @Description Create a new photo for authenticated user, either from a JSON photo_url or as a multipart upload (fields title, caption and file "photo"; JPEG, PNG or WebP, max PHOTO_MAX_UPLOAD_SIZE). With "import": true the photo_url is downloaded and stored like an upload.
 // @Tags photos
 // @Accept json
+// @Accept mpfd
 // @Produce json
-// @Param photo body dto.PhotoUpsertRequest true "Photo create payload"
+// @Param photo body dto.PhotoUpsertRequest false "Photo create payload (JSON)"
+// @Param title formData string false "Title (multipart)"
+// @Param caption formData string false "Caption (multipart)"
+// @Param photo formData file false "Image file (multipart)"
 // @Success 201 {object} dto.BaseResponseSuccessWithData
 // @Failure 400 {object} dto.BaseResponseError
+// @Failure 413 {object} dto.BaseResponseError
+// @Failure 415 {object} dto.BaseResponseError
+// @Failure 422 {object} dto.BaseResponseError
 // @Failure 500 {object} dto.BaseResponseError
 // @Security BearerAuth
 // @Router /photos [post]
@@ -47,64 +88,242 @@ func (p *PhotoController) Create(c *gin.Context) {
 	userIDStr := userData["id"].(string)
 	userID, _ := uuid.Parse(userIDStr)
 
-	var req dto.PhotoUpsertRequest
-
-	// Binding dan validasi
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
-			Success: false,
-			Message: err.Error(),
-		})
-		return
+	photo := models.Photo{
+		ID:     uuid.New(),
+		UserID: userID,
 	}
 
-	photo := models.Photo{
-		ID:       uuid.New(),
-		Title:    req.Title,
-		Caption:  req.Caption,
-		PhotoUrl: req.PhotoUrl,
-		UserID:   userID,
+	if c.ContentType() == "multipart/form-data" {
+		// Upload langsung: file disimpan di Storage dan URL publiknya menjadi photo_url
+		if !p.storeUpload(c, &photo) {
+			return
+		}
+	} else {
+		var req dto.PhotoUpsertRequest
+
+		// Binding dan validasi
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+		photo.Title = req.Title
+		photo.Caption = req.Caption
+		if req.Import {
+			// Import: gambar diunduh dan disimpan sendiri supaya tidak rusak saat link aslinya hilang
+			key, ok := p.importRemote(c, req.PhotoUrl)
+			if !ok {
+				return
+			}
+			photo.Status = models.PhotoStatusProcessing
+			photo.StorageKey = key
+		} else {
+			photo.PhotoUrl = req.PhotoUrl
+		}
 	}
 
 	if err := p.DB.Create(&photo).Error; err != nil {
+		p.deleteStoredFiles(photo.StoredKeys())
 		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
 			Success: false,
 			Message: "Failed to create photo",
 		})
 		return
 	}
-
-	resp := dto.PhotoResponse{
-		ID:        photo.ID.String(),
-		Title:     photo.Title,
-		Caption:   photo.Caption,
-		PhotoUrl:  photo.PhotoUrl,
-		UserID:    photo.UserID.String(),
-		CreatedAt: photo.CreatedAt,
-		UpdatedAt: photo.UpdatedAt,
+	if photo.Status == models.PhotoStatusProcessing {
+		helpers.EnqueuePhotoProcessing(p.DB, photo.ID)
 	}
 
+	// Fan-out ke timeline follower di background supaya response tidak menunggu
+	go func() {
+		if err := helpers.FanOutPhoto(p.DB, photo); err != nil {
+			p.Logger.Printf("Failed to fan out photo %s: %v", photo.ID, err)
+		}
+	}()
+
 	c.JSON(http.StatusCreated, dto.BaseResponseSuccessWithData{
 		Success: true,
 		Message: "Photo created successfully",
-		Data:    resp,
+		Data:    toPhotoResponse(photo, false),
 	})
 }
 
+// storeUpload validates the multipart upload and stores the image; it writes the error response and returns false on failure
+func (p *PhotoController) storeUpload(c *gin.Context, photo *models.Photo) bool {
+	store := helpers.GetStorage()
+	if store == nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "File upload is not configured",
+		})
+		return false
+	}
+
+	// Batasi ukuran body (file + field form) sebelum multipart di-parse
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, helpers.MaxPhotoUploadSize()+1<<20)
+
+	var req dto.PhotoUploadRequest
+	if err := c.ShouldBind(&req); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, dto.BaseResponseError{
+				Success: false,
+				Message: helpers.ErrImageTooLarge.Error(),
+			})
+			return false
+		}
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: err.Error(),
+		})
+		return false
+	}
+
+	fh, err := c.FormFile("photo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Photo file is required",
+		})
+		return false
+	}
+
+	data, contentType, ext, err := helpers.ReadPhotoUpload(fh)
+	if err != nil {
+		c.JSON(imageErrorStatus(err), dto.BaseResponseError{
+			Success: false,
+			Message: err.Error(),
+		})
+		return false
+	}
+
+	key, ok := p.storeOriginal(c, data, contentType, ext)
+	if !ok {
+		return false
+	}
+
+	photo.Title = req.Title
+	photo.Caption = req.Caption
+	photo.Status = models.PhotoStatusProcessing
+	photo.StorageKey = key
+	return true
+}
+
+// importRemote downloads photoURL and stores it as an original for the pipeline; it writes the error response and returns false on failure
+func (p *PhotoController) importRemote(c *gin.Context, photoURL string) (string, bool) {
+	if helpers.GetStorage() == nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Photo import is not configured",
+		})
+		return "", false
+	}
+
+	data, contentType, ext, err := helpers.FetchRemoteImage(c.Request.Context(), photoURL)
+	if err != nil {
+		c.JSON(imageErrorStatus(err), dto.BaseResponseError{
+			Success: false,
+			Message: err.Error(),
+		})
+		return "", false
+	}
+	return p.storeOriginal(c, data, contentType, ext)
+}
+
+// storeOriginal stores a validated image until the pipeline has created its variants
+func (p *PhotoController) storeOriginal(c *gin.Context, data []byte, contentType, ext string) (string, bool) {
+	// File asli (masih dengan EXIF) disimpan di key acak sampai pipeline membuat variant-nya
+	key := fmt.Sprintf("originals/%s%s", uuid.New(), ext)
+	if _, err := helpers.GetStorage().Put(c.Request.Context(), key, data, contentType); err != nil {
+		p.Logger.Printf("Failed to store photo %s: %v", key, err)
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to store photo",
+		})
+		return "", false
+	}
+	return key, true
+}
+
+// imageErrorStatus maps upload and import errors to a response status
+func imageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, helpers.ErrImageTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, helpers.ErrUnsupportedImageType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, helpers.ErrRemoteHostNotAllowed), errors.Is(err, helpers.ErrRemoteImageFetch):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusBadRequest
+}
+
+// deleteStoredFiles removes uploaded files from Storage; failures are only logged
+func (p *PhotoController) deleteStoredFiles(keys []string) {
+	store := helpers.GetStorage()
+	if store == nil {
+		return
+	}
+	for _, key := range keys {
+		if err := store.Delete(context.Background(), key); err != nil {
+			p.Logger.Printf("Failed to delete stored file %s: %v", key, err)
+		}
+	}
+}
+
+// photoListQuery lists photos for GET /photos
+var photoListQuery = listQuery[models.Photo]{
+	sorts: map[string]listSort[models.Photo]{
+		"created_at": {column: "created_at", kind: sortTime, value: func(p models.Photo) any { return p.CreatedAt }},
+		"updated_at": {column: "updated_at", kind: sortTime, value: func(p models.Photo) any { return p.UpdatedAt }},
+		"title":      {column: "title", kind: sortString, value: func(p models.Photo) any { return p.Title }},
+		"like_count": {column: "like_count", kind: sortInt, value: func(p models.Photo) any { return p.LikeCount }},
+	},
+	defaultSort: "-created_at",
+	filters: map[string]listFilter{
+		"user_id":        {column: "user_id", kind: filterUUID},
+		"created_after":  {column: "created_at", kind: filterAfter},
+		"created_before": {column: "created_at", kind: filterBefore},
+	},
+	id: func(p models.Photo) uuid.UUID { return p.ID },
+}
+
 // GetAll godoc
 // @Summary Get all photos
-// @Description Retrieve all photos (with owner info)
+// @Description Retrieve photos (with owner info), keyset paginated: pass meta.next_cursor as ?cursor= for the next page. Photos of private profiles the caller does not follow and of blocked users are left out.
 // @Tags photos
 // @Produce json
-// @Success 200 {object} dto.BaseResponseSuccessWithData
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param sort query string false "created_at, updated_at, title or like_count; prefix with - for descending (default -created_at)"
+// @Param user_id query string false "Only photos of this user"
+// @Param created_after query string false "RFC 3339 timestamp"
+// @Param created_before query string false "RFC 3339 timestamp"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=[]dto.PhotoResponse,meta=dto.ListMeta}
+// @Failure 400 {object} dto.BaseResponseError
 // @Failure 500 {object} dto.BaseResponseError
 // @Security BearerAuth
 // @Router /photos [get]
 func (p *PhotoController) GetAll(c *gin.Context) {
-	var photos []models.Photo
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
 
-	// Preload User and Comments to include related data
-	if err := p.DB.Preload("User").Preload("Comments").Find(&photos).Error; err != nil {
+	// Preload User to include related data; private profiles and blocks hide photos
+	photos, meta, err := photoListQuery.find(c, p.DB.Preload("User").Scopes(visibleContentOf(userID, "photos.user_id")))
+	if err != nil {
+		respondListError(c, err, "Failed to retrieve photos")
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(photos))
+	for _, ph := range photos {
+		ids = append(ids, ph.ID)
+	}
+	liked, err := likedPhotoIDs(p.DB, userID, ids)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
 			Success: false,
 			Message: "Failed to retrieve photos",
@@ -112,29 +331,186 @@ func (p *PhotoController) GetAll(c *gin.Context) {
 		return
 	}
 
-	var respList []dto.PhotoResponse
+	respList := make([]dto.PhotoResponse, 0, len(photos))
 	for _, ph := range photos {
-		respList = append(respList, dto.PhotoResponse{
-			ID:        ph.ID.String(),
-			Title:     ph.Title,
-			Caption:   ph.Caption,
-			PhotoUrl:  ph.PhotoUrl,
-			UserID:    ph.UserID.String(),
-			CreatedAt: ph.CreatedAt,
-			UpdatedAt: ph.UpdatedAt,
-		})
+		respList = append(respList, toPhotoResponse(ph, liked[ph.ID]))
 	}
 
 	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
 		Success: true,
 		Message: "Photos retrieved successfully",
 		Data:    respList,
+		Meta:    meta,
+	})
+}
+
+// GetByID godoc
+// @Summary Get a photo
+// @Description Get a single photo. Related data can be expanded with ?include=user,comments,replies: comments are the newest 100 top-level comments, replies adds the oldest 100 direct replies of each (and implies comments), user adds the authors of the photo, comments and replies.
+// @Tags photos
+// @Produce json
+// @Param photoID path string true "Photo ID"
+// @Param include query string false "Comma separated: user, comments, replies"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.PhotoDetailResponse}
+// @Failure 400 {object} dto.BaseResponseError
+// @Failure 403 {object} dto.BaseResponseError "Private profile"
+// @Failure 404 {object} dto.BaseResponseError
+// @Failure 500 {object} dto.BaseResponseError
+// @Security BearerAuth
+// @Router /photos/{photoID} [get]
+func (p *PhotoController) GetByID(c *gin.Context) {
+	photoID, err := uuid.Parse(c.Param("photoID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid photo ID",
+		})
+		return
+	}
+	includes, ok := parseIncludes(c, "user", "comments", "replies")
+	if !ok {
+		return
+	}
+	withComments := includes["comments"] || includes["replies"]
+
+	query := p.DB
+	if includes["user"] {
+		query = query.Preload("User")
+	}
+	if withComments {
+		query = query.Preload("Comments", func(db *gorm.DB) *gorm.DB {
+			return db.Where("parent_comment_id IS NULL").Order("created_at DESC").Order("id DESC").Limit(maxIncludedComments)
+		})
+		if includes["user"] {
+			query = query.Preload("Comments.User")
+		}
+	}
+	if includes["replies"] {
+		query = query.Preload("Comments.Replies", firstRepliesPerComment(photoID))
+		if includes["user"] {
+			query = query.Preload("Comments.Replies.User")
+		}
+	}
+
+	var photo models.Photo
+	if err := query.First(&photo, "id = ?", photoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.BaseResponseError{
+				Success: false,
+				Message: "Photo not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve photo",
+		})
+		return
+	}
+
+	userData := c.MustGet("userData").(map[string]any)
+	userID, _ := uuid.Parse(userData["id"].(string))
+	if !requireContentVisible(c, p.DB, userID, photo.UserID, "Photo not found") {
+		return
+	}
+	liked, err := likedPhotoIDs(p.DB, userID, []uuid.UUID{photo.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve photo",
+		})
+		return
+	}
+
+	resp := dto.PhotoDetailResponse{PhotoResponse: toPhotoResponse(photo, liked[photo.ID])}
+	if photo.User != nil {
+		user := toUserSummary(*photo.User)
+		resp.User = &user
+	}
+	if withComments {
+		resp.Comments, err = toCommentDetails(p.DB, userID, photo.Comments, includes["replies"])
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+				Success: false,
+				Message: "Failed to retrieve photo",
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Photo retrieved successfully",
+		Data:    resp,
+	})
+}
+
+// RecordView godoc
+// @Summary Record a photo view
+// @Description Counts a view of the photo for the explore ranking. Views by the owner and repeated views within 24 hours are not counted.
+// @Tags photos
+// @Produce json
+// @Param photoID path string true "Photo ID"
+// @Success 200 {object} dto.BaseResponseSuccess
+// @Failure 400 {object} dto.BaseResponseError
+// @Failure 404 {object} dto.BaseResponseError
+// @Failure 500 {object} dto.BaseResponseError
+// @Security BearerAuth
+// @Router /photos/{photoID}/view [post]
+func (p *PhotoController) RecordView(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	photoID, err := uuid.Parse(c.Param("photoID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid photo ID",
+		})
+		return
+	}
+
+	var photo models.Photo
+	if err := p.DB.Select("id", "user_id").First(&photo, "id = ?", photoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.BaseResponseError{
+				Success: false,
+				Message: "Photo not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve photo",
+		})
+		return
+	}
+
+	if photo.UserID != userID {
+		first, err := helpers.MarkPhotoViewed(photoID, userID)
+		if err == nil && first {
+			err = p.DB.Model(&models.Photo{}).Where("id = ?", photoID).
+				UpdateColumn("view_count", gorm.Expr("view_count + 1")).Error
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+				Success: false,
+				Message: "Failed to record view",
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccess{
+		Success: true,
+		Message: "View recorded",
 	})
 }
 
 // Update godoc
 // @Summary Update a photo
-// @Description Update a photo by id. Requires authorization middleware to ensure ownership.
+// @Description Update a photo by id. Requires authorization middleware to ensure ownership. With "import": true the new photo_url is downloaded and processed like an upload.
 // @Tags photos
 // @Accept json
 // @Produce json
@@ -143,6 +519,9 @@ func (p *PhotoController) GetAll(c *gin.Context) {
 // @Success 200 {object} dto.BaseResponseSuccessWithData
 // @Failure 400 {object} dto.BaseResponseError
 // @Failure 404 {object} dto.BaseResponseError
+// @Failure 413 {object} dto.BaseResponseError
+// @Failure 415 {object} dto.BaseResponseError
+// @Failure 422 {object} dto.BaseResponseError
 // @Failure 500 {object} dto.BaseResponseError
 // @Security BearerAuth
 // @Router /photos/{photoID} [put]
@@ -182,6 +561,12 @@ func (p *PhotoController) Update(c *gin.Context) {
 		return
 	}
 
+	oldURL, oldKeys := photo.PhotoUrl, photo.StoredKeys()
+	if req.Import {
+		p.updateImported(c, &photo, req, oldKeys)
+		return
+	}
+
 	updatedData := models.Photo{
 		Title:    req.Title,
 		Caption:  req.Caption,
@@ -196,6 +581,21 @@ func (p *PhotoController) Update(c *gin.Context) {
 		return
 	}
 
+	// photo_url diganti: file upload dan variant yang lama tidak dipakai lagi
+	if len(oldKeys) > 0 && oldURL != req.PhotoUrl {
+		if err := p.DB.Model(&photo).Updates(map[string]any{
+			"storage_key":   "",
+			"variant_keys":  "",
+			"thumbnail_url": "",
+			"medium_url":    "",
+			"width":         0,
+			"height":        0,
+			"status":        models.PhotoStatusReady,
+		}).Error; err == nil {
+			p.deleteStoredFiles(oldKeys)
+		}
+	}
+
 	// Ambil kembali data setelah update
 	if err := p.DB.Preload("User").First(&photo, "id = ?", photoID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
@@ -205,18 +605,70 @@ func (p *PhotoController) Update(c *gin.Context) {
 		return
 	}
 
+	// liked_by_me untuk pemanggil (bisa moderator, bukan pemilik)
+	userData := c.MustGet("userData").(map[string]any)
+	callerID, _ := uuid.Parse(userData["id"].(string))
+	liked, err := likedPhotoIDs(p.DB, callerID, []uuid.UUID{photo.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve updated photo",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Photo updated successfully",
+		Data:    toPhotoResponse(photo, liked[photo.ID]),
+	})
+}
+
+// updateImported replaces the image of a photo with an imported copy of req.PhotoUrl and queues it for processing
+func (p *PhotoController) updateImported(c *gin.Context, photo *models.Photo, req dto.PhotoUpsertRequest, oldKeys []string) {
+	key, ok := p.importRemote(c, req.PhotoUrl)
+	if !ok {
+		return
+	}
+
+	if err := p.DB.Model(photo).Updates(map[string]any{
+		"title":         req.Title,
+		"caption":       req.Caption,
+		"photo_url":     "",
+		"storage_key":   key,
+		"variant_keys":  "",
+		"thumbnail_url": "",
+		"medium_url":    "",
+		"width":         0,
+		"height":        0,
+		"status":        models.PhotoStatusProcessing,
+	}).Error; err != nil {
+		p.deleteStoredFiles([]string{key})
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to update photo",
+		})
+		return
+	}
+	p.deleteStoredFiles(oldKeys)
+	helpers.EnqueuePhotoProcessing(p.DB, photo.ID)
+
+	userData := c.MustGet("userData").(map[string]any)
+	callerID, _ := uuid.Parse(userData["id"].(string))
+	liked, err := likedPhotoIDs(p.DB, callerID, []uuid.UUID{photo.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve updated photo",
+		})
+		return
+	}
+
+	// Variant belum ada, status "processing" sampai pipeline selesai
 	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
 		Success: true,
 		Message: "Photo updated successfully",
-		Data: dto.PhotoResponse{
-			ID:        photo.ID.String(),
-			Title:     photo.Title,
-			Caption:   photo.Caption,
-			PhotoUrl:  photo.PhotoUrl,
-			UserID:    photo.UserID.String(),
-			CreatedAt: photo.CreatedAt,
-			UpdatedAt: photo.UpdatedAt,
-		},
+		Data:    toPhotoResponse(*photo, liked[photo.ID]),
 	})
 }
 
@@ -266,6 +718,7 @@ func (p *PhotoController) Delete(c *gin.Context) {
 		})
 		return
 	}
+	p.deleteStoredFiles(photo.StoredKeys())
 
 	c.JSON(http.StatusOK, dto.BaseResponseSuccess{
 		Success: true,
@@ -0,0 +1,250 @@
+package controllers
+
+import (
+	"errors"
+	"mygram-api/dto"
+	"mygram-api/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// likedPhotoIDs returns which of the photos are liked by the user
+func likedPhotoIDs(db *gorm.DB, userID uuid.UUID, photoIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	liked := make(map[uuid.UUID]bool)
+	if len(photoIDs) == 0 {
+		return liked, nil
+	}
+	var ids []uuid.UUID
+	if err := db.Model(&models.PhotoLike{}).
+		Where("user_id = ? AND photo_id IN ?", userID, photoIDs).
+		Pluck("photo_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		liked[id] = true
+	}
+	return liked, nil
+}
+
+// Like godoc
+// @Summary Like a photo
+// @Description Likes a photo. Calling it again is a no-op. Requires JWT token.
+// @Tags photos
+// @Produce json
+// @Param photoID path string true "Photo ID"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.PhotoLikeResponse}
+// @Failure 400 {object} dto.BaseResponseError
+// @Failure 403 {object} dto.BaseResponseError "Blocked"
+// @Failure 404 {object} dto.BaseResponseError
+// @Failure 500 {object} dto.BaseResponseError
+// @Security BearerAuth
+// @Router /photos/{photoID}/like [post]
+func (p *PhotoController) Like(c *gin.Context) {
+	p.setLike(c, true)
+}
+
+// Unlike godoc
+// @Summary Unlike a photo
+// @Description Removes the caller's like of a photo. Calling it again is a no-op. Requires JWT token.
+// @Tags photos
+// @Produce json
+// @Param photoID path string true "Photo ID"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.PhotoLikeResponse}
+// @Failure 400 {object} dto.BaseResponseError
+// @Failure 404 {object} dto.BaseResponseError
+// @Failure 500 {object} dto.BaseResponseError
+// @Security BearerAuth
+// @Router /photos/{photoID}/like [delete]
+func (p *PhotoController) Unlike(c *gin.Context) {
+	p.setLike(c, false)
+}
+
+// setLike adds or removes the caller's like and keeps photos.like_count in sync
+func (p *PhotoController) setLike(c *gin.Context, like bool) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	photoID, err := uuid.Parse(c.Param("photoID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid photo ID",
+		})
+		return
+	}
+
+	var photo models.Photo
+	if err := p.DB.Select("id", "user_id").First(&photo, "id = ?", photoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.BaseResponseError{
+				Success: false,
+				Message: "Photo not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve photo",
+		})
+		return
+	}
+
+	if like {
+		blocked, err := isBlockedBetween(p.DB, userID, photo.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+				Success: false,
+				Message: "Failed to like photo",
+			})
+			return
+		}
+		if blocked {
+			c.JSON(http.StatusForbidden, dto.BaseResponseError{
+				Success: false,
+				Message: "You cannot like this photo",
+			})
+			return
+		}
+	}
+
+	// Unique (photo, user) membuat operasi idempotent; counter hanya berubah jika baris like benar-benar
+	// ditambah / dihapus, di transaksi yang sama, dengan UPDATE atomik (like_count = like_count ± 1)
+	var likeCount int64
+	err = p.DB.Transaction(func(tx *gorm.DB) error {
+		var result *gorm.DB
+		delta := 1
+		if like {
+			result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.PhotoLike{UserID: userID, PhotoID: photoID})
+		} else {
+			result = tx.Where("photo_id = ? AND user_id = ?", photoID, userID).Delete(&models.PhotoLike{})
+			delta = -1
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			if err := tx.Model(&models.Photo{}).Where("id = ?", photoID).
+				UpdateColumn("like_count", gorm.Expr("like_count + ?", delta)).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.Photo{}).Where("id = ?", photoID).Select("like_count").Scan(&likeCount).Error
+	})
+	if err != nil {
+		message := "Failed to like photo"
+		if !like {
+			message = "Failed to unlike photo"
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	message := "Photo liked successfully"
+	if !like {
+		message = "Photo unliked successfully"
+	}
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: message,
+		Data: dto.PhotoLikeResponse{
+			PhotoID:   photoID.String(),
+			LikeCount: likeCount,
+			LikedByMe: like,
+		},
+	})
+}
+
+// ListLikes godoc
+// @Summary List likes of a photo
+// @Description Lists the users who liked a photo, newest first. Requires JWT token.
+// @Tags photos
+// @Produce json
+// @Param photoID path string true "Photo ID"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.PhotoLikeListResponse}
+// @Failure 400 {object} dto.BaseResponseError
+// @Failure 403 {object} dto.BaseResponseError "Private profile"
+// @Failure 404 {object} dto.BaseResponseError
+// @Failure 500 {object} dto.BaseResponseError
+// @Security BearerAuth
+// @Router /photos/{photoID}/likes [get]
+func (p *PhotoController) ListLikes(c *gin.Context) {
+	photoID, err := uuid.Parse(c.Param("photoID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid photo ID",
+		})
+		return
+	}
+
+	var photo models.Photo
+	if err := p.DB.Select("id", "user_id").First(&photo, "id = ?", photoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.BaseResponseError{
+				Success: false,
+				Message: "Photo not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve photo",
+		})
+		return
+	}
+
+	userData := c.MustGet("userData").(map[string]any)
+	userID, _ := uuid.Parse(userData["id"].(string))
+	if !requireContentVisible(c, p.DB, userID, photo.UserID, "Photo not found") {
+		return
+	}
+
+	page, limit := parsePageParams(c)
+	query := p.DB.Model(&models.PhotoLike{}).Where("photo_id = ?", photoID)
+
+	var total int64
+	var likes []models.PhotoLike
+	err = query.Count(&total).Error
+	if err == nil {
+		err = query.Preload("User").Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&likes).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve likes",
+		})
+		return
+	}
+
+	users := make([]dto.PhotoLikeUserResponse, 0, len(likes))
+	for _, l := range likes {
+		if l.User == nil {
+			continue
+		}
+		users = append(users, dto.PhotoLikeUserResponse{
+			UserSummaryResponse: toUserSummary(*l.User),
+			LikedAt:             l.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Likes retrieved successfully",
+		Data: dto.PhotoLikeListResponse{
+			Users: users,
+			Page:  page,
+			Limit: limit,
+			Total: total,
+		},
+	})
+}
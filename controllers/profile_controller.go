@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"errors"
+	"mygram-api/dto"
+	"mygram-api/helpers"
+	"mygram-api/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// buildPublicProfile maps a user to the public profile DTO, including follower, following and photo counts
+func buildPublicProfile(db *gorm.DB, user models.User) (dto.PublicProfileResponse, error) {
+	profile := dto.PublicProfileResponse{
+		ID:          user.ID.String(),
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		AvatarURL:   user.AvatarURL,
+		Website:     user.Website,
+		IsPrivate:   user.IsPrivate,
+		CreatedAt:   user.CreatedAt,
+	}
+
+	if err := db.Model(&models.Follow{}).Where("following_id = ? AND status = ?", user.ID, models.FollowStatusAccepted).Count(&profile.FollowerCount).Error; err != nil {
+		return profile, err
+	}
+	if err := db.Model(&models.Follow{}).Where("follower_id = ? AND status = ?", user.ID, models.FollowStatusAccepted).Count(&profile.FollowingCount).Error; err != nil {
+		return profile, err
+	}
+	if err := db.Model(&models.Photo{}).Where("user_id = ?", user.ID).Count(&profile.PhotoCount).Error; err != nil {
+		return profile, err
+	}
+	return profile, nil
+}
+
+// GetMe godoc
+// @Summary Get my profile
+// @Description Returns the full profile of the authenticated user. Requires JWT token.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.MyProfileResponse}
+// @Failure 401 {object} dto.BaseResponseError "Unauthorized"
+// @Failure 404 {object} dto.BaseResponseError "Account not found"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/me [get]
+func (u *UserController) GetMe(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	var user models.User
+	if err := u.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, dto.BaseResponseError{
+			Success: false,
+			Message: "Account not found",
+		})
+		return
+	}
+
+	profile, err := buildPublicProfile(u.DB, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve profile",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Profile retrieved successfully",
+		Data: dto.MyProfileResponse{
+			PublicProfileResponse: profile,
+			Email:                 user.Email,
+			EmailVerifiedAt:       user.EmailVerifiedAt,
+			Age:                   user.Age,
+			Role:                  user.Role,
+			TOTPEnabled:           user.TOTPEnabledAt != nil,
+			UpdatedAt:             user.UpdatedAt,
+		},
+	})
+}
+
+// GetProfile godoc
+// @Summary Get a user's public profile
+// @Description Returns the public profile of a user by username, with follower, following and photo counts and the caller's follow relationship. Requires JWT token.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param username path string true "Username"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.PublicProfileResponse}
+// @Failure 401 {object} dto.BaseResponseError "Unauthorized"
+// @Failure 404 {object} dto.BaseResponseError "User not found"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/{username} [get]
+func (u *UserController) GetProfile(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	viewerID, _ := uuid.Parse(userIDStr)
+
+	var user models.User
+	if err := u.DB.First(&user, "username = ?", c.Param("username")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.BaseResponseError{
+				Success: false,
+				Message: "User not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve profile",
+		})
+		return
+	}
+
+	profile, err := buildPublicProfile(u.DB, user)
+	if err == nil && viewerID != user.ID {
+		var rel dto.RelationshipResponse
+		rel, err = loadRelationship(u.DB, viewerID, user.ID)
+		profile.Relationship = &rel
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve profile",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Profile retrieved successfully",
+		Data:    profile,
+	})
+}
+
+// UpdateProfile godoc
+// @Summary Update my profile
+// @Description Updates display name, bio, avatar URL, website and the private flag of the authenticated user. Only the fields present in the body are changed; an empty string clears a field. Requires JWT token.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body dto.UserProfileUpdateRequest true "Profile fields"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.PublicProfileResponse}
+// @Failure 400 {object} dto.BaseResponseError "Invalid request body or validation error"
+// @Failure 401 {object} dto.BaseResponseError "Unauthorized"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/profile [put]
+func (u *UserController) UpdateProfile(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	var req dto.UserProfileUpdateRequest
+
+	// 1. Binding dan Validasi Request Body
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	var user models.User
+	if err := u.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, dto.BaseResponseError{
+			Success: false,
+			Message: "Account not found",
+		})
+		return
+	}
+
+	// 2. Update hanya field yang dikirim (map supaya nilai kosong / false juga tersimpan).
+	// Profil yang menjadi public otomatis menerima follow request yang masih pending.
+	updates := map[string]any{}
+	for column, value := range map[string]*string{
+		"display_name": req.DisplayName,
+		"bio":          req.Bio,
+		"avatar_url":   req.AvatarURL,
+		"website":      req.Website,
+	} {
+		if value != nil {
+			updates[column] = *value
+		}
+	}
+	if req.IsPrivate != nil {
+		updates["is_private"] = *req.IsPrivate
+	}
+
+	var acceptedFollowerIDs []uuid.UUID
+	err := u.DB.Transaction(func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			if err := tx.Model(&user).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		if req.IsPrivate == nil || *req.IsPrivate {
+			return nil
+		}
+		pending := tx.Model(&models.Follow{}).Where("following_id = ? AND status = ?", user.ID, models.FollowStatusPending).Session(&gorm.Session{})
+		if err := pending.Pluck("follower_id", &acceptedFollowerIDs).Error; err != nil {
+			return err
+		}
+		return pending.Updates(map[string]any{"status": models.FollowStatusAccepted, "accepted_at": time.Now()}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to update profile",
+		})
+		return
+	}
+
+	// Follower baru harus melihat foto user ini di feed mereka
+	if err := helpers.InvalidateFeed(acceptedFollowerIDs...); err != nil {
+		u.Logger.Printf("Failed to invalidate feeds after accepting follow requests of user %s: %v", user.ID, err)
+	}
+
+	profile, err := buildPublicProfile(u.DB, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve profile",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Profile updated successfully",
+		Data:    profile,
+	})
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"errors"
+	"mygram-api/dto"
+	"mygram-api/helpers"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListSessions godoc
+// @Summary List login sessions
+// @Description Lists the devices where the authenticated user is logged in, most recently active first. Sessions whose refresh token expired are not listed. Requires JWT token.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=[]dto.SessionResponse}
+// @Failure 401 {object} dto.BaseResponseError "Unauthorized"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/sessions [get]
+func (u *UserController) ListSessions(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+	currentSessionID, _ := userData["sid"].(string)
+	sid, _ := uuid.Parse(currentSessionID)
+
+	// Sesi yang refresh token-nya sudah kedaluwarsa tidak bisa dipakai lagi, jadi tidak ditampilkan
+	sessions, err := helpers.ListActiveSessions(u.DB, userID, sid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve sessions",
+		})
+		return
+	}
+
+	respList := []dto.SessionResponse{}
+	for _, session := range sessions {
+		respList = append(respList, dto.SessionResponse{
+			ID:         session.ID.String(),
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			Current:    session.ID.String() == currentSessionID,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Sessions retrieved successfully",
+		Data:    respList,
+	})
+}
+
+// RevokeSession godoc
+// @Summary Revoke a login session
+// @Description Logs one of the authenticated user's devices out: its refresh token stops working and its access tokens are rejected. Requires JWT token.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param sessionID path string true "Session ID"
+// @Success 200 {object} dto.BaseResponseSuccess
+// @Failure 400 {object} dto.BaseResponseError "Invalid ID format"
+// @Failure 404 {object} dto.BaseResponseError "Session not found"
+// @Failure 500 {object} dto.BaseResponseError
+// @Router /users/sessions/{sessionID} [delete]
+func (u *UserController) RevokeSession(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	sessionID, err := uuid.Parse(c.Param("sessionID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid session ID",
+		})
+		return
+	}
+
+	// Hanya sesi milik user sendiri yang bisa di-revoke
+	if err := helpers.RevokeSession(u.DB, userID, sessionID); err != nil {
+		if errors.Is(err, helpers.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, dto.BaseResponseError{
+				Success: false,
+				Message: "Session not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to revoke session",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccess{
+		Success: true,
+		Message: "Session revoked successfully",
+	})
+}
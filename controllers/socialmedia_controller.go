@@ -85,26 +85,45 @@ func (smc *SocialMediaController) Create(c *gin.Context) {
 	})
 }
 
+// socialMediaListQuery lists social media entries for GET /socialmedias
+var socialMediaListQuery = listQuery[models.SocialMedia]{
+	sorts: map[string]listSort[models.SocialMedia]{
+		"created_at": {column: "created_at", kind: sortTime, value: func(s models.SocialMedia) any { return s.CreatedAt }},
+		"name":       {column: "name", kind: sortString, value: func(s models.SocialMedia) any { return s.Name }},
+	},
+	defaultSort: "-created_at",
+	filters: map[string]listFilter{
+		"user_id":        {column: "user_id", kind: filterUUID},
+		"created_after":  {column: "created_at", kind: filterAfter},
+		"created_before": {column: "created_at", kind: filterBefore},
+	},
+	id: func(s models.SocialMedia) uuid.UUID { return s.ID },
+}
+
 // GetAll godoc
 // @Summary Get all social media entries
-// @Description Retrieve all social media entries
+// @Description Retrieve social media entries, keyset paginated: pass meta.next_cursor as ?cursor= for the next page
 // @Tags socialmedias
 // @Produce json
-// @Success 200 {object} dto.BaseResponseSuccessWithData
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param sort query string false "created_at or name; prefix with - for descending (default -created_at)"
+// @Param user_id query string false "Only entries of this user"
+// @Param created_after query string false "RFC 3339 timestamp"
+// @Param created_before query string false "RFC 3339 timestamp"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=[]dto.SocialMediaResponse,meta=dto.ListMeta}
+// @Failure 400 {object} dto.BaseResponseError
 // @Failure 500 {object} dto.BaseResponseError
 // @Security BearerAuth
 // @Router /socialmedias [get]
 func (smc *SocialMediaController) GetAll(c *gin.Context) {
-	var socials []models.SocialMedia
-	if err := smc.DB.Preload("User").Find(&socials).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
-			Success: false,
-			Message: "Failed to retrieve social medias",
-		})
+	socials, meta, err := socialMediaListQuery.find(c, smc.DB.Preload("User"))
+	if err != nil {
+		respondListError(c, err, "Failed to retrieve social medias")
 		return
 	}
 
-	var respList []dto.SocialMediaResponse
+	respList := make([]dto.SocialMediaResponse, 0, len(socials))
 	for _, s := range socials {
 		respList = append(respList, dto.SocialMediaResponse{
 			ID:             s.ID.String(),
@@ -120,6 +139,77 @@ func (smc *SocialMediaController) GetAll(c *gin.Context) {
 		Success: true,
 		Message: "Social medias retrieved successfully",
 		Data:    respList,
+		Meta:    meta,
+	})
+}
+
+// GetByID godoc
+// @Summary Get a social media entry
+// @Description Get a single social media entry; the owner can be expanded with ?include=user
+// @Tags socialmedias
+// @Produce json
+// @Param socialMediaID path string true "Social media ID"
+// @Param include query string false "Comma separated: user"
+// @Success 200 {object} dto.BaseResponseSuccessWithData{data=dto.SocialMediaDetailResponse}
+// @Failure 400 {object} dto.BaseResponseError
+// @Failure 404 {object} dto.BaseResponseError
+// @Failure 500 {object} dto.BaseResponseError
+// @Security BearerAuth
+// @Router /socialmedias/{socialMediaID} [get]
+func (smc *SocialMediaController) GetByID(c *gin.Context) {
+	socialID, err := uuid.Parse(c.Param("socialmediaID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: "Invalid social media ID",
+		})
+		return
+	}
+	includes, ok := parseIncludes(c, "user")
+	if !ok {
+		return
+	}
+
+	query := smc.DB
+	if includes["user"] {
+		query = query.Preload("User")
+	}
+
+	var social models.SocialMedia
+	if err := query.First(&social, "id = ?", socialID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.BaseResponseError{
+				Success: false,
+				Message: "Social media not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to retrieve social media",
+		})
+		return
+	}
+
+	resp := dto.SocialMediaDetailResponse{
+		SocialMediaResponse: dto.SocialMediaResponse{
+			ID:             social.ID.String(),
+			Name:           social.Name,
+			SocialMediaUrl: social.SocialMediaUrl,
+			UserID:         social.UserID.String(),
+			CreatedAt:      social.CreatedAt,
+			UpdatedAt:      social.UpdatedAt,
+		},
+	}
+	if social.User != nil {
+		user := toUserSummary(*social.User)
+		resp.User = &user
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
+		Success: true,
+		Message: "Social media retrieved successfully",
+		Data:    resp,
 	})
 }
 
@@ -138,7 +228,7 @@ func (smc *SocialMediaController) GetAll(c *gin.Context) {
 // @Security BearerAuth
 // @Router /socialmedias/{socialMediaID} [put]
 func (smc *SocialMediaController) Update(c *gin.Context) {
-	socialIDStr := c.Param("socialMediaID")
+	socialIDStr := c.Param("socialmediaID")
 	socialID, err := uuid.Parse(socialIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
@@ -223,7 +313,7 @@ func (smc *SocialMediaController) Update(c *gin.Context) {
 // @Security BearerAuth
 // @Router /socialmedias/{socialMediaID} [delete]
 func (smc *SocialMediaController) Delete(c *gin.Context) {
-	socialIDStr := c.Param("socialMediaID")
+	socialIDStr := c.Param("socialmediaID")
 	socialID, err := uuid.Parse(socialIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
@@ -3,10 +3,14 @@ package controllers
 import (
 	"errors"
 	"log"
+	"math"
 	"mygram-api/dto"
 	"mygram-api/helpers"
 	"mygram-api/models"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -79,7 +83,12 @@ func (u *UserController) Register(c *gin.Context) {
 	// 4. Send Welcome Email (Non-blocking via Goroutine)
 	helpers.SendWelcomeEmail(user.Email, user.Username) // Modification: Welcome Email + Goroutine
 
-	// 5. Return Response
+	// 5. Send email verification link
+	if err := sendEmailVerification(u.DB, user, user.Email); err != nil {
+		u.Logger.Printf("Failed to send verification email to %s: %v", user.Email, err)
+	}
+
+	// 6. Return Response
 	response := dto.UserRegisterResponse{
 		ID:       user.ID.String(),
 		Username: user.Username,
@@ -96,7 +105,7 @@ func (u *UserController) Register(c *gin.Context) {
 
 // Login godoc
 // @Summary User login
-// @Description Authenticates a user and returns a JWT token.
+// @Description Authenticates a user and returns a JWT token. When 2FA is enabled, an mfa_pending token is returned instead and the login is completed with /auth/mfa/verify.
 // @Tags users
 // @Accept json
 // @Produce json
@@ -104,6 +113,8 @@ func (u *UserController) Register(c *gin.Context) {
 // @Success 200 {object} dto.UserLoginResponse "Successfully logged in"
 // @Failure 400 {object} dto.BaseResponseError "Invalid request body or validation error"
 // @Failure 401 {object} dto.BaseResponseError "Invalid email or password"
+// @Failure 403 {object} dto.BaseResponseError "Account suspended"
+// @Failure 429 {object} dto.BaseResponseError "Too many failed login attempts"
 // @Router /users/login [post]
 func (u *UserController) Login(c *gin.Context) {
 	var req dto.UserLoginRequest // DTO untuk request body
@@ -118,17 +129,17 @@ func (u *UserController) Login(c *gin.Context) {
 		return
 	}
 
-	// 2. Pengecekan Email di Database
-	// Mencari user berdasarkan email. Hanya ambil password untuk pengecekan.
-	if err := u.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// Jika email tidak ditemukan
-			c.JSON(http.StatusNotFound, dto.BaseResponseError{
-				Success: false,
-				Message: "Account not found",
-			}) // Status 404
-			return
-		}
+	// 2. Login throttle per email dan IP (progressive delay, lalu lockout sementara)
+	if err := helpers.CheckLoginThrottle(req.Email, c.ClientIP()); err != nil {
+		abortLoginThrottled(c, u.Logger, err)
+		return
+	}
+
+	// 3. Pengecekan Email di Database
+	// Akun tidak ditemukan dan password salah diperlakukan sama (status, pesan dan waktu bcrypt),
+	// supaya endpoint ini tidak membocorkan email mana yang terdaftar.
+	err := u.DB.Where("email = ?", req.Email).First(&user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		// Error database lainnya
 		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
 			Success: false,
@@ -136,11 +147,23 @@ func (u *UserController) Login(c *gin.Context) {
 		})
 		return
 	}
+	found := err == nil
 
-	// 3. Pengecekan Password (Menggunakan Bcrypt)
+	// 4. Pengecekan Password (Menggunakan Bcrypt)
 	// req.Password adalah password mentah, user.Password adalah hash dari database
-	if isMatch := helpers.CheckPasswordHash(req.Password, user.Password); !isMatch {
-		// Jika password tidak cocok
+	passwordHash := user.Password
+	if !found {
+		passwordHash = dummyPasswordHash()
+	}
+	if isMatch := helpers.CheckPasswordHash(req.Password, passwordHash); !isMatch || !found {
+		locked, err := helpers.RecordLoginFailure(req.Email, c.ClientIP())
+		if err != nil {
+			u.Logger.Printf("Failed to record login failure: %v", err)
+		}
+		if locked && found {
+			helpers.SendAccountLockedEmail(user.Email, user.Username, helpers.LoginLockoutDuration())
+		}
+
 		c.JSON(http.StatusUnauthorized, dto.BaseResponseError{
 			Success: false,
 			Message: "Invalid email or password",
@@ -148,9 +171,109 @@ func (u *UserController) Login(c *gin.Context) {
 		return
 	}
 
-	// 4. Generate JWT Token
-	// Menggunakan helper yang sudah kita buat sebelumnya (helpers/auth.go)
-	token, err := helpers.CreateToken(user.ID, user.Email)
+	if err := helpers.ResetLoginFailures(req.Email); err != nil {
+		u.Logger.Printf("Failed to reset login failures: %v", err)
+	}
+
+	// 5. Generate token (atau token mfa_pending jika 2FA aktif)
+	completeLogin(c, u.DB, user)
+}
+
+var (
+	dummyHash     string
+	dummyHashOnce sync.Once
+)
+
+// dummyPasswordHash is compared against when the account does not exist,
+// so both failure paths take the same bcrypt time.
+func dummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = helpers.HashPassword(uuid.NewString())
+	})
+	return dummyHash
+}
+
+// abortLoginThrottled answers 429 with Retry-After for a throttled login, or 500 on a Redis error
+func abortLoginThrottled(c *gin.Context, logger *log.Logger, err error) {
+	var throttled *helpers.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		logger.Printf("Login throttle error: %v", err)
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to login",
+		})
+		return
+	}
+
+	retryAfter := int64(math.Ceil(throttled.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+	c.JSON(http.StatusTooManyRequests, dto.BaseResponseError{
+		Success: false,
+		Message: throttled.Error(),
+	})
+}
+
+// Refresh godoc
+// @Summary Refresh access token
+// @Description Exchanges a refresh token for a new access token and a new (rotated) refresh token. Reusing an already used refresh token revokes the whole session.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body dto.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} dto.UserLoginResponse "New token pair"
+// @Failure 400 {object} dto.BaseResponseError "Invalid request body"
+// @Failure 401 {object} dto.BaseResponseError "Invalid, expired or reused refresh token"
+// @Router /auth/refresh [post]
+func (u *UserController) Refresh(c *gin.Context) {
+	var req dto.RefreshTokenRequest
+
+	// 1. Binding Request Body
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// 2. Rotate refresh token (mark as used + issue successor in the same family)
+	consumed, refreshToken, err := helpers.RotateRefreshToken(u.DB, req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, helpers.ErrRefreshTokenInvalid) ||
+			errors.Is(err, helpers.ErrRefreshTokenExpired) ||
+			errors.Is(err, helpers.ErrRefreshTokenReused) {
+			if errors.Is(err, helpers.ErrRefreshTokenReused) {
+				// Access tokens of the stolen session must stop working too
+				if revokeErr := helpers.RevokeSession(u.DB, consumed.UserID, consumed.FamilyID); revokeErr != nil && !errors.Is(revokeErr, helpers.ErrSessionNotFound) {
+					u.Logger.Printf("Failed to revoke session %s: %v", consumed.FamilyID, revokeErr)
+				}
+				u.Logger.Printf("Refresh token reuse detected for user %s, session %s revoked", consumed.UserID, consumed.FamilyID)
+			}
+			c.JSON(http.StatusUnauthorized, dto.BaseResponseError{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to refresh token",
+		})
+		return
+	}
+
+	// 3. Ambil user untuk claims access token
+	var user models.User
+	if err := u.DB.First(&user, "id = ?", consumed.UserID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, dto.BaseResponseError{
+			Success: false,
+			Message: "Account not found",
+		})
+		return
+	}
+
+	// 4. Generate access token for the same session
+	token, err := helpers.CreateToken(user.ID, user.Email, helpers.WithSessionID(consumed.FamilyID), helpers.WithRole(user.Role))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
 			Success: false,
@@ -159,17 +282,92 @@ func (u *UserController) Login(c *gin.Context) {
 		return
 	}
 
-	// 5. Respon Sukses
-	response := dto.UserLoginResponse{
-		Token: token, // token: "jwt string"
+	c.JSON(http.StatusOK, dto.UserLoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(helpers.AccessTokenTTL().Seconds()),
+	})
+}
+
+// Logout godoc
+// @Summary Log out the current session
+// @Description Revokes the access token used for this request and the refresh tokens of its session. Requires JWT token.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.BaseResponseSuccess "Successfully logged out"
+// @Failure 401 {object} dto.BaseResponseError "Unauthorized"
+// @Failure 500 {object} dto.BaseResponseError "Failed to revoke token"
+// @Router /auth/logout [post]
+func (u *UserController) Logout(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+
+	// 1. Masukkan access token ke denylist sampai expired
+	jti, _ := userData["jti"].(string)
+	exp, _ := userData["exp"].(float64)
+	if jti != "" {
+		if err := helpers.RevokeToken(jti, time.Unix(int64(exp), 0)); err != nil {
+			c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+				Success: false,
+				Message: "Failed to revoke token",
+			})
+			return
+		}
 	}
 
-	c.JSON(http.StatusOK, response) // Status 200
+	// 2. Revoke this session and its refresh tokens so it cannot be refreshed anymore
+	userID, _ := uuid.Parse(userData["id"].(string))
+	if sid, ok := userData["sid"].(string); ok {
+		if sessionID, err := uuid.Parse(sid); err == nil {
+			if err := helpers.RevokeSession(u.DB, userID, sessionID); err != nil && !errors.Is(err, helpers.ErrSessionNotFound) {
+				c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+					Success: false,
+					Message: "Failed to revoke session",
+				})
+				return
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccess{
+		Success: true,
+		Message: "Successfully logged out",
+	})
+}
+
+// LogoutAll godoc
+// @Summary Log out everywhere
+// @Description Revokes every access token and refresh token issued to the authenticated user. Requires JWT token.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.BaseResponseSuccess "Successfully logged out from all sessions"
+// @Failure 401 {object} dto.BaseResponseError "Unauthorized"
+// @Failure 500 {object} dto.BaseResponseError "Failed to revoke sessions"
+// @Router /auth/logout-all [post]
+func (u *UserController) LogoutAll(c *gin.Context) {
+	userData := c.MustGet("userData").(map[string]any)
+	userIDStr := userData["id"].(string)
+	userID, _ := uuid.Parse(userIDStr)
+
+	if err := helpers.RevokeUserSessions(u.DB, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to revoke sessions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.BaseResponseSuccess{
+		Success: true,
+		Message: "Successfully logged out from all sessions",
+	})
 }
 
 // Update godoc
 // @Summary Update user's account details
-// @Description Update authenticated user's email and username. Requires JWT token.
+// @Description Update authenticated user's email and username. A new email is only applied after it is confirmed via the emailed link. Requires JWT token.
 // @Tags users
 // @Accept json
 // @Produce json
@@ -198,15 +396,42 @@ func (u *UserController) Update(c *gin.Context) {
 		return
 	}
 
-	// 2. Buat map data yang akan diupdate
+	// 2. Ambil data user saat ini untuk mengecek perubahan email
+	if err := u.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, dto.BaseResponseError{
+			Success: false,
+			Message: "Account not found",
+		})
+		return
+	}
+
+	// Email baru tidak langsung dipakai: email lama tetap aktif sampai email baru dikonfirmasi
+	emailChanged := req.Email != user.Email
+	if emailChanged {
+		var count int64
+		if err := u.DB.Model(&models.User{}).Where("email = ? AND id <> ?", req.Email, userID).Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+				Success: false,
+				Message: "Failed to update user account",
+			})
+			return
+		}
+		if count > 0 {
+			c.JSON(http.StatusBadRequest, dto.BaseResponseError{
+				Success: false,
+				Message: "Email is already registered",
+			})
+			return
+		}
+	}
+
 	updatedData := models.User{
-		Email:    req.Email,
 		Username: req.Username,
 	}
 
 	// 3. Update data di database
 	// Menggunakan u.DB (dari Dependency Injection)
-	// Kita hanya mengupdate Email dan Username
+	// Kita hanya mengupdate Username, Email diupdate setelah verifikasi
 	if err := u.DB.Model(&user).Where("id = ?", userID).Updates(updatedData).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
 			Success: false,
@@ -215,6 +440,20 @@ func (u *UserController) Update(c *gin.Context) {
 		return
 	}
 
+	message := "User account updated successfully"
+	var pendingEmail string
+	if emailChanged {
+		if err := sendEmailVerification(u.DB, user, req.Email); err != nil {
+			c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+				Success: false,
+				Message: "Failed to send verification email",
+			})
+			return
+		}
+		pendingEmail = req.Email
+		message = "User account updated successfully. Please confirm your new email address"
+	}
+
 	// 4. Ambil data yang sudah diupdate untuk respons
 	if err := u.DB.First(&user, "id = ?", userID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
@@ -226,16 +465,17 @@ func (u *UserController) Update(c *gin.Context) {
 
 	// 5. Respon Sukses (Status 200)
 	response := dto.UserUpdateResponse{
-		ID:        user.ID.String(),
-		Username:  user.Username,
-		Email:     user.Email,
-		Age:       user.Age,
-		UpdatedAt: user.UpdatedAt,
+		ID:           user.ID.String(),
+		Username:     user.Username,
+		Email:        user.Email,
+		PendingEmail: pendingEmail,
+		Age:          user.Age,
+		UpdatedAt:    user.UpdatedAt,
 	}
 
 	c.JSON(http.StatusOK, dto.BaseResponseSuccessWithData{
 		Success: true,
-		Message: "User account updated successfully",
+		Message: message,
 		Data:    response,
 	})
 }
@@ -275,3 +515,89 @@ func (u *UserController) Delete(c *gin.Context) {
 		Message: "Your account has been successfully deleted",
 	})
 }
+
+// issueLoginTokens starts a new login session for the user (recording the device):
+// a new refresh token family plus a short-lived access token bound to it.
+func issueLoginTokens(c *gin.Context, db *gorm.DB, user models.User) (dto.UserLoginResponse, error) {
+	session, err := helpers.CreateSession(db, user.ID, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		return dto.UserLoginResponse{}, err
+	}
+	sessionID := session.ID
+
+	refreshToken, err := helpers.CreateRefreshToken(db, user.ID, sessionID)
+	if err != nil {
+		return dto.UserLoginResponse{}, err
+	}
+
+	token, err := helpers.CreateToken(user.ID, user.Email, helpers.WithSessionID(sessionID), helpers.WithRole(user.Role))
+	if err != nil {
+		return dto.UserLoginResponse{}, err
+	}
+
+	return dto.UserLoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(helpers.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// completeLogin finishes a successful first-factor login (password or external provider).
+// With 2FA enabled it returns a short-lived mfa_pending token, otherwise a new token pair.
+func completeLogin(c *gin.Context, db *gorm.DB, user models.User) {
+	// Akun yang di-suspend tidak bisa login
+	if user.IsSuspended(time.Now()) {
+		c.JSON(http.StatusForbidden, dto.BaseResponseError{
+			Success: false,
+			Message: helpers.SuspensionMessage(user),
+		})
+		return
+	}
+
+	// 2FA aktif: token asli diberikan oleh /auth/mfa/verify
+	if user.TOTPEnabledAt != nil {
+		// Challenge lama yang sudah kedaluwarsa sekalian dibersihkan
+		if err := db.Where("user_id = ? AND expires_at < ?", user.ID, time.Now()).Delete(&models.MFAChallenge{}).Error; err != nil {
+			log.Printf("Failed to delete expired MFA challenges of user %s: %v", user.ID, err)
+		}
+		challenge := models.MFAChallenge{UserID: user.ID, ExpiresAt: time.Now().Add(mfaPendingTokenTTL)}
+		if err := db.Create(&challenge).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+				Success: false,
+				Message: "Failed to generate token",
+			})
+			return
+		}
+		mfaToken, err := helpers.CreateToken(user.ID, user.Email,
+			helpers.WithTokenType(helpers.TokenTypeMFAPending),
+			helpers.WithTokenID(challenge.ID),
+			helpers.WithTTL(mfaPendingTokenTTL))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+				Success: false,
+				Message: "Failed to generate token",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, dto.MFAPendingResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+			ExpiresIn:   int64(mfaPendingTokenTTL.Seconds()),
+		})
+		return
+	}
+
+	// Generate access token + refresh token (new login session)
+	response, err := issueLoginTokens(c, db, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.BaseResponseError{
+			Success: false,
+			Message: "Failed to generate token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response) // Status 200
+}
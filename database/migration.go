@@ -24,6 +24,19 @@ func MigrateTables(db *gorm.DB) {
 		&models.Photo{},
 		&models.Comment{},
 		&models.SocialMedia{},
+		&models.RefreshToken{},
+		&models.UserToken{},
+		&models.MFARecoveryCode{},
+		&models.MFAChallenge{},
+		&models.UserIdentity{},
+		&models.OIDCLoginState{},
+		&models.PersonalAccessToken{},
+		&models.Session{},
+		&models.AuditLog{},
+		&models.Follow{},
+		&models.UserBlock{},
+		&models.PhotoLike{},
+		&models.CommentReaction{},
 	)
 
 	log.Println("Database migration completed successfully!")
@@ -0,0 +1,45 @@
+package dto
+
+import "time"
+
+// UserRoleUpdateRequest represents the request body for PUT /admin/users/:userID/role
+type UserRoleUpdateRequest struct {
+	Role string `json:"role" binding:"required,oneof=user moderator admin" example:"moderator"`
+}
+
+// AdminUserResponse is a user as seen by admins
+type AdminUserResponse struct {
+	ID               string     `json:"id"`
+	Username         string     `json:"username"`
+	Email            string     `json:"email"`
+	EmailVerifiedAt  *time.Time `json:"email_verified_at"`
+	Age              int        `json:"age"`
+	Role             string     `json:"role"`
+	SuspendedAt      *time.Time `json:"suspended_at,omitempty"`
+	SuspendedUntil   *time.Time `json:"suspended_until,omitempty"`
+	SuspensionReason string     `json:"suspension_reason,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// AdminUserListResponse is one page of GET /admin/users
+type AdminUserListResponse struct {
+	Users []AdminUserResponse `json:"users"`
+	Page  int                 `json:"page"`
+	Limit int                 `json:"limit"`
+	Total int64               `json:"total"`
+}
+
+// AdminUserDetailResponse is a user with the number of resources they own
+type AdminUserDetailResponse struct {
+	AdminUserResponse
+	PhotoCount       int64 `json:"photo_count"`
+	CommentCount     int64 `json:"comment_count"`
+	SocialMediaCount int64 `json:"social_media_count"`
+}
+
+// UserSuspendRequest represents the request body for POST /admin/users/:userID/suspend
+type UserSuspendRequest struct {
+	Reason string     `json:"reason" binding:"required,max=500" example:"Spam"`
+	Until  *time.Time `json:"until" example:"2030-01-01T00:00:00Z"` // Omit to suspend indefinitely
+}
@@ -6,9 +6,17 @@ type BaseResponseSuccess struct {
 }
 
 type BaseResponseSuccessWithData struct {
-	Success bool   `json:"success" example:"true"`
-	Message string `json:"message"`
-	Data    any    `json:"data"`
+	Success bool      `json:"success" example:"true"`
+	Message string    `json:"message"`
+	Data    any       `json:"data"`
+	Meta    *ListMeta `json:"meta,omitempty"`
+}
+
+// ListMeta is the pagination metadata of list responses; pass next_cursor as ?cursor= for the next page
+type ListMeta struct {
+	Limit      int    `json:"limit" example:"20"`
+	HasMore    bool   `json:"has_more" example:"true"`
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJzIjoiLWNyZWF0ZWRfYXQiLCJ2Ijo..."`
 }
 
 type BaseResponseError struct {
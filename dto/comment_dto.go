@@ -17,12 +17,40 @@ type CommentReplyRequest struct {
 
 // CommentResponse represents the response body for comment resources
 type CommentResponse struct {
-	ID              string    `json:"id"`
-	UserID          string    `json:"user_id"`
-	PhotoID         string    `json:"photo_id"`
-	Message         string    `json:"message"`
-	ParentCommentID *string   `json:"parent_comment_id,omitempty"`
-	RepliesCount    int       `json:"replies_count"` // Untuk efisiensi
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID              string           `json:"id"`
+	UserID          string           `json:"user_id"`
+	PhotoID         string           `json:"photo_id"`
+	Message         string           `json:"message"`
+	ParentCommentID *string          `json:"parent_comment_id,omitempty"`
+	RepliesCount    int              `json:"replies_count"`                         // Untuk efisiensi
+	Reactions       map[string]int64 `json:"reactions" example:"like:3,love:1"`     // Jumlah per jenis reaksi
+	MyReactions     []string         `json:"my_reactions,omitempty" example:"like"` // Reaksi user yang sedang login
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+// CommentDetailResponse is returned by GET /comments/:commentID; user, photo and replies are only set when requested with ?include=
+type CommentDetailResponse struct {
+	CommentResponse
+	User    *UserSummaryResponse    `json:"user,omitzero"`
+	Photo   *PhotoResponse          `json:"photo,omitzero"`
+	Replies []CommentDetailResponse `json:"replies,omitzero"` // Direct replies, oldest first
+}
+
+// CommentThreadResponse is the reply tree of GET /comments/:commentID/thread
+type CommentThreadResponse struct {
+	CommentDetailResponse
+	Truncated bool `json:"truncated"` // More than COMMENT_THREAD_MAX_NODES comments; the newest replies of the last level are left out
+}
+
+// CommentReactionRequest represents the request body for POST /comments/:commentID/reactions
+type CommentReactionRequest struct {
+	Type string `json:"type" binding:"required" example:"like"`
+}
+
+// CommentReactionsResponse is the reaction summary of a comment after adding or removing a reaction
+type CommentReactionsResponse struct {
+	CommentID   string           `json:"comment_id"`
+	Reactions   map[string]int64 `json:"reactions"`
+	MyReactions []string         `json:"my_reactions"`
 }
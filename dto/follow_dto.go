@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+// FollowResponse is returned by POST /users/:userID/follow
+type FollowResponse struct {
+	FollowingID string `json:"following_id"`
+	Status      string `json:"status" example:"accepted"` // "accepted" or "pending" (private profile)
+}
+
+// FollowUserResponse is one entry of a follower / following list
+type FollowUserResponse struct {
+	UserSummaryResponse
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// FollowListResponse is one page of GET /users/:username/followers and /following
+type FollowListResponse struct {
+	Users []FollowUserResponse `json:"users"`
+	Page  int                  `json:"page"`
+	Limit int                  `json:"limit"`
+	Total int64                `json:"total"`
+}
+
+// FollowRequestResponse is a pending follow request to the authenticated user
+type FollowRequestResponse struct {
+	ID        string              `json:"id"`
+	Follower  UserSummaryResponse `json:"follower"`
+	CreatedAt time.Time           `json:"created_at"`
+}
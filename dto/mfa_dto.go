@@ -0,0 +1,36 @@
+package dto
+
+// MFAEnrollResponse is returned by POST /users/mfa/enroll
+type MFAEnrollResponse struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	OtpauthURI string `json:"otpauth_uri" example:"otpauth://totp/MyGram:john@example.com?secret=JBSWY3DPEHPK3PXP&issuer=MyGram"`
+}
+
+// MFACodeRequest represents the request body for POST /users/mfa/confirm
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+// MFAConfirmResponse contains the recovery codes, shown only once
+type MFAConfirmResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFADisableRequest represents the request body for DELETE /users/mfa
+type MFADisableRequest struct {
+	Password string `json:"password" binding:"required" example:"password123"`
+	Code     string `json:"code" binding:"required" example:"123456"` // TOTP code or recovery code
+}
+
+// MFAPendingResponse is returned by POST /auth/login when the account has 2FA enabled
+type MFAPendingResponse struct {
+	MFARequired bool   `json:"mfa_required" example:"true"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int64  `json:"expires_in" example:"300"`
+}
+
+// MFAVerifyRequest represents the request body for POST /auth/mfa/verify
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required" example:"123456"` // TOTP code or recovery code
+}
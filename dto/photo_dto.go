@@ -2,20 +2,98 @@ package dto
 
 import "time"
 
-// PhotoUpsertRequest represents the request body for POST /photos
+// PhotoUpsertRequest represents the request body for POST /photos.
+// With import=true the server downloads photo_url and stores its own copy instead of linking to it.
 type PhotoUpsertRequest struct {
 	Title    string `json:"title" binding:"required" example:"Sunset over the beach"`
 	Caption  string `json:"caption" example:"A beautiful sunset captured at the shore"`
 	PhotoUrl string `json:"photo_url" binding:"required,url" example:"https://example.com/photos/1.jpg"`
+	Import   bool   `json:"import" example:"false"`
+}
+
+// PhotoUploadRequest represents the multipart form of POST /photos; the image is sent in the "photo" file field
+type PhotoUploadRequest struct {
+	Title   string `form:"title" binding:"required" example:"Sunset over the beach"`
+	Caption string `form:"caption" example:"A beautiful sunset captured at the shore"`
 }
 
 // PhotoResponse represents the response body for photo resources
 type PhotoResponse struct {
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Caption   string    `json:"caption"`
-	PhotoUrl  string    `json:"photo_url"`
-	UserID    string    `json:"user_id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        string                 `json:"id"`
+	Title     string                 `json:"title"`
+	Caption   string                 `json:"caption"`
+	PhotoUrl  string                 `json:"photo_url"`
+	UserID    string                 `json:"user_id"`
+	Status    string                 `json:"status" example:"ready"` // "processing" until the variants of an upload exist, "ready" or "failed"
+	Width     int                    `json:"width,omitempty"`
+	Height    int                    `json:"height,omitempty"`
+	Variants  *PhotoVariantsResponse `json:"variants,omitempty"`
+	LikeCount int64                  `json:"like_count"`
+	LikedByMe bool                   `json:"liked_by_me"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// PhotoVariantsResponse lists the resized variants of an uploaded photo
+type PhotoVariantsResponse struct {
+	Thumbnail string `json:"thumbnail"` // 150x150, center cropped
+	Medium    string `json:"medium"`    // max 640px
+	Full      string `json:"full"`      // max 1440px
+}
+
+// PhotoDetailResponse is returned by GET /photos/:photoID; user and comments are only set when requested with ?include=
+type PhotoDetailResponse struct {
+	PhotoResponse
+	User     *UserSummaryResponse    `json:"user,omitzero"`
+	Comments []CommentDetailResponse `json:"comments,omitzero"` // Top-level comments, newest first
+}
+
+// PhotoLikeResponse is returned by POST and DELETE /photos/:photoID/like
+type PhotoLikeResponse struct {
+	PhotoID   string `json:"photo_id"`
+	LikeCount int64  `json:"like_count"`
+	LikedByMe bool   `json:"liked_by_me"`
+}
+
+// PhotoLikeUserResponse is one entry of GET /photos/:photoID/likes
+type PhotoLikeUserResponse struct {
+	UserSummaryResponse
+	LikedAt time.Time `json:"liked_at"`
+}
+
+// PhotoLikeListResponse is one page of GET /photos/:photoID/likes, newest first
+type PhotoLikeListResponse struct {
+	Users []PhotoLikeUserResponse `json:"users"`
+	Page  int                     `json:"page"`
+	Limit int                     `json:"limit"`
+	Total int64                   `json:"total"`
+}
+
+// FeedPhotoResponse is one photo of GET /feed
+type FeedPhotoResponse struct {
+	ID           string                 `json:"id"`
+	Title        string                 `json:"title"`
+	Caption      string                 `json:"caption"`
+	PhotoUrl     string                 `json:"photo_url"`
+	Status       string                 `json:"status"`
+	Variants     *PhotoVariantsResponse `json:"variants,omitempty"`
+	Owner        UserSummaryResponse    `json:"owner"`
+	LikeCount    int64                  `json:"like_count"`
+	CommentCount int64                  `json:"comment_count"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+// FeedResponse is one page of GET /feed, newest first
+type FeedResponse struct {
+	Photos     []FeedPhotoResponse `json:"photos"`
+	NextCursor string              `json:"next_cursor,omitempty"` // pass as ?cursor= to get the next page
+	HasMore    bool                `json:"has_more"`
+}
+
+// ExploreResponse is one page of GET /explore, best ranked first
+type ExploreResponse struct {
+	Photos  []FeedPhotoResponse `json:"photos"`
+	Page    int                 `json:"page"`
+	Limit   int                 `json:"limit"`
+	HasMore bool                `json:"has_more"`
 }
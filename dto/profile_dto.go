@@ -0,0 +1,57 @@
+package dto
+
+import "time"
+
+// UserProfileUpdateRequest represents the request body for PUT /users/profile.
+// Omitted fields keep their current value; an empty string clears a field.
+type UserProfileUpdateRequest struct {
+	DisplayName *string `json:"display_name" binding:"omitempty,max=50" example:"John Doe"`
+	Bio         *string `json:"bio" binding:"omitempty,max=300" example:"Photographer from Jakarta"`
+	AvatarURL   *string `json:"avatar_url" binding:"omitempty,eq=|url" example:"https://example.com/avatar.jpg"`
+	Website     *string `json:"website" binding:"omitempty,eq=|url" example:"https://johndoe.dev"`
+	IsPrivate   *bool   `json:"is_private" example:"false"`
+}
+
+// PublicProfileResponse is what other users can see of a user. It never contains the email.
+type PublicProfileResponse struct {
+	ID             string    `json:"id"`
+	Username       string    `json:"username"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	AvatarURL      string    `json:"avatar_url"`
+	Website        string    `json:"website"`
+	IsPrivate      bool      `json:"is_private"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+	PhotoCount     int64     `json:"photo_count"`
+	CreatedAt      time.Time `json:"created_at"`
+	// Relationship between the caller and this user, omitted on the caller's own profile
+	Relationship *RelationshipResponse `json:"relationship,omitempty"`
+}
+
+// RelationshipResponse describes the follow relationship between the caller and another user
+type RelationshipResponse struct {
+	Following  bool `json:"following"`   // Caller follows the user
+	Requested  bool `json:"requested"`   // Caller's follow request is pending
+	FollowedBy bool `json:"followed_by"` // The user follows the caller
+	Mutual     bool `json:"mutual"`      // Both follow each other
+}
+
+// UserSummaryResponse is the short form of a user used in lists
+type UserSummaryResponse struct {
+	ID          string `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	AvatarURL   string `json:"avatar_url"`
+}
+
+// MyProfileResponse is the full profile of the authenticated user (GET /users/me)
+type MyProfileResponse struct {
+	PublicProfileResponse
+	Email           string     `json:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	Age             int        `json:"age"`
+	Role            string     `json:"role"`
+	TOTPEnabled     bool       `json:"totp_enabled"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
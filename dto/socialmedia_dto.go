@@ -23,3 +23,9 @@ type SocialMediaResponse struct {
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
+
+// SocialMediaDetailResponse is returned by GET /socialmedias/:socialmediaID; user is only set with ?include=user
+type SocialMediaDetailResponse struct {
+	SocialMediaResponse
+	User *UserSummaryResponse `json:"user,omitzero"`
+}
@@ -23,8 +23,17 @@ type UserLoginRequest struct {
 	Password string `json:"password" binding:"required,min=6" example:"password123"`
 }
 
+// UserLoginResponse is returned by POST /auth/login and POST /auth/refresh
 type UserLoginResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"` // Short-lived access token (JWT)
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type" example:"Bearer"`
+	ExpiresIn    int64  `json:"expires_in" example:"900"` // Access token lifetime in seconds
+}
+
+// RefreshTokenRequest represents the request body for POST /auth/refresh
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 // UserUpdateRequest merepresentasikan request body untuk PUT /users
@@ -35,9 +44,73 @@ type UserUpdateRequest struct {
 
 // UserUpdateResponse merepresentasikan response body sukses untuk PUT /users
 type UserUpdateResponse struct {
-	ID        string    `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Age       int       `json:"age"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	Email        string    `json:"email"`
+	PendingEmail string    `json:"pending_email,omitempty"` // New email waiting for confirmation
+	Age          int       `json:"age"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ForgotPasswordRequest represents the request body for POST /auth/password/forgot
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email" example:"john@example.com"`
+}
+
+// ResetPasswordRequest represents the request body for POST /auth/password/reset
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6" example:"newpassword123"`
+}
+
+// ChangePasswordRequest represents the request body for PUT /users/password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required" example:"password123"`
+	NewPassword     string `json:"new_password" binding:"required,min=6" example:"newpassword123"`
+}
+
+// ChangePasswordResponse carries the new access token for the current session after a password change
+type ChangePasswordResponse struct {
+	Token     string `json:"token"`
+	TokenType string `json:"token_type" example:"Bearer"`
+	ExpiresIn int64  `json:"expires_in" example:"900"`
+}
+
+// OIDCLoginResponse is returned by GET /auth/oidc/{provider}/login
+type OIDCLoginResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// AccessTokenCreateRequest represents the request body for POST /users/tokens
+type AccessTokenCreateRequest struct {
+	Name          string   `json:"name" binding:"required,max=100" example:"backup script"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,required" example:"photos:read,comments:read"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,gte=1,lte=365" example:"90"` // Omit for a token that never expires
+}
+
+// AccessTokenResponse represents a personal access token (without its secret)
+type AccessTokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix" example:"mgp_Ab12"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// AccessTokenCreateResponse includes the token secret, shown only once
+type AccessTokenCreateResponse struct {
+	AccessTokenResponse
+	Token string `json:"token" example:"mgp_Ab12..."`
+}
+
+// SessionResponse is one login session (device) of the user
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	Current    bool      `json:"current"` // true for the session making the request
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
 }
@@ -0,0 +1,49 @@
+package helpers
+
+import (
+	"mygram-api/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CommentThreadMaxDepth is the deepest reply level returned by a thread (COMMENT_THREAD_MAX_DEPTH, default 10)
+func CommentThreadMaxDepth() int {
+	return int(envInt64("COMMENT_THREAD_MAX_DEPTH", 10))
+}
+
+// CommentThreadMaxNodes is the most comments returned by a thread (COMMENT_THREAD_MAX_NODES, default 500)
+func CommentThreadMaxNodes() int {
+	return int(envInt64("COMMENT_THREAD_MAX_NODES", 500))
+}
+
+// commentThreadQuery walks the reply tree below a comment in a single recursive query (PostgreSQL and SQLite).
+// The root has depth 0; replies deeper than @max_depth are not loaded and at most @max_nodes rows are
+// returned, level by level.
+const commentThreadQuery = `
+WITH RECURSIVE thread AS (
+	SELECT comments.*, 0 AS depth FROM comments WHERE comments.id = @root
+	UNION ALL
+	SELECT comments.*, thread.depth + 1 FROM comments
+	JOIN thread ON comments.parent_comment_id = thread.id
+	WHERE thread.depth < @max_depth
+)
+SELECT * FROM thread ORDER BY depth, created_at, id LIMIT @max_nodes`
+
+// LoadCommentThread returns the comment rootID and its replies down to maxDepth levels, parents before
+// their replies and siblings oldest first. At most maxNodes comments are returned; truncated reports
+// whether the tree had more. It returns gorm.ErrRecordNotFound when the root does not exist.
+func LoadCommentThread(db *gorm.DB, rootID uuid.UUID, maxDepth, maxNodes int) (comments []models.Comment, truncated bool, err error) {
+	// One extra row tells whether the tree was cut off
+	if err := db.Raw(commentThreadQuery, map[string]any{"root": rootID, "max_depth": maxDepth, "max_nodes": maxNodes + 1}).
+		Scan(&comments).Error; err != nil {
+		return nil, false, err
+	}
+	if len(comments) == 0 {
+		return nil, false, gorm.ErrRecordNotFound
+	}
+	if len(comments) > maxNodes {
+		return comments[:maxNodes], true, nil
+	}
+	return comments, false, nil
+}
@@ -10,55 +10,151 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-// Global secret key from environment variable
+// Global secret key from environment variable, used for HS256 when no JWT_KEY_DIR is configured
 var secretKey = os.Getenv("JWT_SECRET_KEY")
 
+// hmacSecret returns the HS256 secret. The environment is read again when the package was
+// initialised before .env was loaded.
+func hmacSecret() []byte {
+	if secretKey != "" {
+		return []byte(secretKey)
+	}
+	return []byte(os.Getenv("JWT_SECRET_KEY"))
+}
+
+// Timestamps (iat, exp) are issued with millisecond precision, so a token issued in the same
+// second as a "logout everywhere" is still recognised as older or newer than the cutoff.
+func init() {
+	jwt.TimePrecision = time.Millisecond
+}
+
+// Token types carried in the "typ" claim
+const (
+	TokenTypeAccess     = "access"      // regular API access token
+	TokenTypeMFAPending = "mfa_pending" // password was checked, second factor still required
+)
+
 // Claims struct defines the structure of the JWT payload
 type Claims struct {
-	ID    string `json:"id"`
-	Email string `json:"email"`
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	SessionID string `json:"sid,omitempty"` // Refresh token family the token was issued for
+	TokenType string `json:"typ"`
+	Role      string `json:"role,omitempty"` // models.RoleUser / RoleModerator / RoleAdmin
 	jwt.RegisteredClaims
 }
 
+// TokenOption customises the claims of a token created by CreateToken.
+type TokenOption func(*Claims)
+
+// WithSessionID binds the access token to a login session (refresh token family).
+func WithSessionID(sessionID uuid.UUID) TokenOption {
+	return func(c *Claims) {
+		c.SessionID = sessionID.String()
+	}
+}
+
+// WithRole puts the user's role into the token. A role change applies to new tokens only.
+func WithRole(role string) TokenOption {
+	return func(c *Claims) {
+		c.Role = role
+	}
+}
+
+// WithTokenType changes the token type (default TokenTypeAccess).
+func WithTokenType(tokenType string) TokenOption {
+	return func(c *Claims) {
+		c.TokenType = tokenType
+	}
+}
+
+// WithTTL overrides the default access token lifetime.
+func WithTTL(ttl time.Duration) TokenOption {
+	return func(c *Claims) {
+		c.ExpiresAt = jwt.NewNumericDate(c.IssuedAt.Add(ttl))
+	}
+}
+
+// WithTokenID sets the jti instead of a random one, for tokens the server keeps state for.
+func WithTokenID(id uuid.UUID) TokenOption {
+	return func(c *Claims) {
+		c.RegisteredClaims.ID = id.String()
+	}
+}
+
+// AccessTokenTTL returns the lifetime of access tokens.
+// It can be configured with JWT_ACCESS_TOKEN_TTL (e.g. "15m"), default 15 minutes.
+func AccessTokenTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("JWT_ACCESS_TOKEN_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return 15 * time.Minute
+}
+
 // CreateTokenFunc is an overridable function (default implementation below) so tests can mock it.
-var CreateTokenFunc = func(userID uuid.UUID, email string) (string, error) {
-	// Set the expiration time for the token (e.g., 24 hours from now)
-	expirationTime := time.Now().Add(24 * time.Hour)
+var CreateTokenFunc = func(userID uuid.UUID, email string, opts ...TokenOption) (string, error) {
+	// Access tokens are short-lived; clients use their refresh token to get a new one
+	now := time.Now()
+	expirationTime := now.Add(AccessTokenTTL())
 
 	claims := &Claims{
-		ID:    userID.String(),
-		Email: email,
+		ID:        userID.String(),
+		Email:     email,
+		TokenType: TokenTypeAccess,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(), // jti, unique per token
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
+	for _, opt := range opts {
+		opt(claims)
+	}
 
-	// Create the token using the claims and HMAC signing method
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	// Sign with the active asymmetric key (kid in header), or HMAC when no keys are configured
+	if keys := currentSigningKeys(); keys != nil {
+		token := jwt.NewWithClaims(keys.active.method, claims)
+		token.Header["kid"] = keys.active.kid
+		return token.SignedString(keys.active.private)
+	}
 
-	// Sign the token with the secret key
-	tokenString, err := token.SignedString([]byte(secretKey))
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString(hmacSecret())
 	if err != nil {
 		return "", err
 	}
 	return tokenString, nil
 }
 
-// CreateToken calls the overridable GenerateTokenFunc.
-func CreateToken(userID uuid.UUID, email string) (string, error) {
-	return CreateTokenFunc(userID, email)
+// CreateToken calls the overridable CreateTokenFunc.
+func CreateToken(userID uuid.UUID, email string, opts ...TokenOption) (string, error) {
+	return CreateTokenFunc(userID, email, opts...)
 }
 
 // VerifyToken verifies the JWT token string and returns the claims (payload).
 func VerifyToken(tokenString string) (jwt.MapClaims, error) {
 	// 1. Parse the token
 	token, err := jwt.ParseWithClaims(tokenString, jwt.MapClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Verify that the signing method is HMAC
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		keys := currentSigningKeys()
+
+		// Without a key directory only HMAC tokens are accepted
+		if keys == nil {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, errors.New("invalid signing method")
+			}
+			return hmacSecret(), nil
+		}
+
+		// Otherwise look up the key by kid; the algorithm must match the key type
+		kid, _ := token.Header["kid"].(string)
+		key, ok := keys.keys[kid]
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, errors.New("invalid signing method")
 		}
-		return []byte(secretKey), nil
+		return key.private.Public(), nil
 	})
 
 	if err != nil {
@@ -0,0 +1,220 @@
+package helpers
+
+import (
+	"context"
+	"log"
+	"math"
+	"mygram-api/database"
+	"mygram-api/models"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	// exploreKey holds the ranking as a sorted set of "<photo id>:<owner id>" scored by ExploreScore
+	exploreKey = "explore:ranking"
+	// ExploreRankingSize is the number of top photos kept in the ranking
+	ExploreRankingSize = 1000
+	// exploreMaxCandidates caps the number of recent photos scored per run
+	exploreMaxCandidates = 10000
+)
+
+// Engagement weights and time decay of the explore score
+const (
+	exploreLikeWeight    = 3.0
+	exploreCommentWeight = 4.0
+	exploreReplyWeight   = 2.0
+	exploreViewWeight    = 0.2
+	exploreGravity       = 1.5
+)
+
+// ExploreEntry is a ranked photo
+type ExploreEntry struct {
+	PhotoID uuid.UUID
+	UserID  uuid.UUID
+	Score   float64
+}
+
+// ExploreWindow is how old a photo may be to appear in explore
+func ExploreWindow() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("EXPLORE_WINDOW")); err == nil && d > 0 {
+		return d
+	}
+	return 72 * time.Hour
+}
+
+// ExploreRefreshInterval is how often the background worker recomputes the ranking
+func ExploreRefreshInterval() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("EXPLORE_REFRESH_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return 5 * time.Minute
+}
+
+// ExploreScore weighs the engagement of a photo and decays it with age (Hacker News style gravity)
+func ExploreScore(likes, comments, replies, views int64, age time.Duration) float64 {
+	engagement := exploreLikeWeight*float64(likes) +
+		exploreCommentWeight*float64(comments) +
+		exploreReplyWeight*float64(replies) +
+		exploreViewWeight*float64(views)
+	hours := math.Max(age.Hours(), 0)
+	return (engagement + 1) / math.Pow(hours+2, exploreGravity)
+}
+
+// RankExplorePhotos scores the recent photos of public profiles, best first, at most ExploreRankingSize
+func RankExplorePhotos(db *gorm.DB, now time.Time) ([]ExploreEntry, error) {
+	since := now.Add(-ExploreWindow())
+
+	var photos []struct {
+		ID        uuid.UUID
+		UserID    uuid.UUID
+		LikeCount int64
+		ViewCount int64
+		CreatedAt time.Time
+	}
+	if err := db.Model(&models.Photo{}).
+		Select("photos.id, photos.user_id, photos.like_count, photos.view_count, photos.created_at").
+		Joins("JOIN users ON users.id = photos.user_id").
+		Where("photos.created_at >= ? AND photos.status = ? AND users.is_private = ?", since, models.PhotoStatusReady, false).
+		Order("photos.created_at DESC").
+		Limit(exploreMaxCandidates).
+		Scan(&photos).Error; err != nil {
+		return nil, err
+	}
+	if len(photos) == 0 {
+		return nil, nil
+	}
+
+	// Top-level comments and replies counted separately
+	var counts []struct {
+		PhotoID  uuid.UUID
+		Comments int64
+		Replies  int64
+	}
+	if err := db.Model(&models.Comment{}).
+		Select("comments.photo_id, "+
+			"SUM(CASE WHEN comments.parent_comment_id IS NULL THEN 1 ELSE 0 END) AS comments, "+
+			"SUM(CASE WHEN comments.parent_comment_id IS NOT NULL THEN 1 ELSE 0 END) AS replies").
+		Joins("JOIN photos ON photos.id = comments.photo_id").
+		Where("photos.created_at >= ?", since).
+		Group("comments.photo_id").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	type commentCount struct{ comments, replies int64 }
+	byPhoto := make(map[uuid.UUID]commentCount, len(counts))
+	for _, cc := range counts {
+		byPhoto[cc.PhotoID] = commentCount{cc.Comments, cc.Replies}
+	}
+
+	entries := make([]ExploreEntry, 0, len(photos))
+	for _, ph := range photos {
+		cc := byPhoto[ph.ID]
+		entries = append(entries, ExploreEntry{
+			PhotoID: ph.ID,
+			UserID:  ph.UserID,
+			Score:   ExploreScore(ph.LikeCount, cc.comments, cc.replies, ph.ViewCount, now.Sub(ph.CreatedAt)),
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Score > entries[j].Score })
+	if len(entries) > ExploreRankingSize {
+		entries = entries[:ExploreRankingSize]
+	}
+	return entries, nil
+}
+
+// RefreshExploreRanking recomputes the ranking and swaps it into Redis atomically
+func RefreshExploreRanking(db *gorm.DB) error {
+	rdb := database.GetRedis()
+	if rdb == nil {
+		return nil
+	}
+	entries, err := RankExplorePhotos(db, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if len(entries) == 0 {
+		return rdb.Del(ctx, exploreKey).Err()
+	}
+
+	members := make([]redis.Z, 0, len(entries))
+	for _, e := range entries {
+		members = append(members, redis.Z{Score: e.Score, Member: e.PhotoID.String() + ":" + e.UserID.String()})
+	}
+	tmpKey := exploreKey + ":tmp"
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, tmpKey)
+		pipe.ZAdd(ctx, tmpKey, members...)
+		pipe.Rename(ctx, tmpKey, exploreKey)
+		return nil
+	})
+	return err
+}
+
+// ReadExploreRanking returns the ranking computed by the worker, best first.
+// ok is false when Redis is unavailable or the ranking has not been computed yet.
+func ReadExploreRanking() (entries []ExploreEntry, ok bool, err error) {
+	rdb := database.GetRedis()
+	if rdb == nil {
+		return nil, false, nil
+	}
+	members, err := rdb.ZRevRangeWithScores(context.Background(), exploreKey, 0, -1).Result()
+	if err != nil || len(members) == 0 {
+		return nil, false, err
+	}
+
+	for _, m := range members {
+		photoID, userID, found := strings.Cut(m.Member.(string), ":")
+		if !found {
+			continue
+		}
+		pid, err1 := uuid.Parse(photoID)
+		uid, err2 := uuid.Parse(userID)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		entries = append(entries, ExploreEntry{PhotoID: pid, UserID: uid, Score: m.Score})
+	}
+	return entries, true, nil
+}
+
+// StartExploreWorker recomputes the explore ranking now and then at every interval. No-op without Redis.
+// With several app instances only the one holding the lock of the current interval does the work.
+func StartExploreWorker(db *gorm.DB, interval time.Duration) {
+	rdb := database.GetRedis()
+	if rdb == nil {
+		return
+	}
+	go func() {
+		for {
+			locked, err := rdb.SetNX(context.Background(), exploreKey+":lock", 1, interval).Result()
+			if err != nil {
+				log.Printf("Failed to acquire explore ranking lock: %v", err)
+			} else if locked {
+				if err := RefreshExploreRanking(db); err != nil {
+					log.Printf("Failed to refresh explore ranking: %v", err)
+				}
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// MarkPhotoViewed reports whether this is the user's first view of the photo in the last 24 hours,
+// so repeated views do not inflate the explore score. Without Redis every view counts.
+func MarkPhotoViewed(photoID, userID uuid.UUID) (bool, error) {
+	rdb := database.GetRedis()
+	if rdb == nil {
+		return true, nil
+	}
+	key := "photo_viewed:" + photoID.String() + ":" + userID.String()
+	return rdb.SetNX(context.Background(), key, 1, 24*time.Hour).Result()
+}
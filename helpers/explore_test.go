@@ -0,0 +1,17 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExploreScoreDecaysWithAge(t *testing.T) {
+	t.Parallel()
+
+	fresh := ExploreScore(10, 2, 1, 100, time.Hour)
+	stale := ExploreScore(10, 2, 1, 100, 48*time.Hour)
+	assert.Greater(t, fresh, stale)
+	assert.Greater(t, ExploreScore(1, 0, 0, 0, time.Hour), ExploreScore(0, 0, 0, 0, time.Hour))
+}
@@ -0,0 +1,261 @@
+package helpers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mygram-api/database"
+	"mygram-api/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	// feedKeyPrefix + user ID: the user's home timeline (fan-out on write). All members have
+	// score 0 and sort lexicographically as "<created_at unix micro>:<photo id>", newest last.
+	feedKeyPrefix = "feed:"
+	// feedReadySuffix marks a timeline as complete; without it readers fall back to the database
+	feedReadySuffix = ":ready"
+	// feedGenerationSuffix counts changes (invalidation, fan-out) to a timeline, so a rebuild
+	// that read the database before a change does not store its stale result
+	feedGenerationSuffix = ":gen"
+	// feedRebuildSuffix is the lock held while a timeline is rebuilt, one rebuild per user at a time
+	feedRebuildSuffix  = ":rebuilding"
+	feedRebuildLockTTL = 30 * time.Second
+	// FeedTimelineSize is the number of newest photos kept per timeline
+	FeedTimelineSize = 500
+	feedTimelineTTL  = 7 * 24 * time.Hour
+)
+
+var ErrInvalidFeedCursor = errors.New("invalid cursor")
+
+// FeedEntry is a photo in a timeline
+type FeedEntry struct {
+	PhotoID   uuid.UUID
+	CreatedAt time.Time
+}
+
+// member is the sortable timeline member of the entry; microseconds match the database precision
+func (e FeedEntry) member() string {
+	return fmt.Sprintf("%019d:%s", e.CreatedAt.UnixMicro(), e.PhotoID)
+}
+
+// Cursor returns the opaque keyset cursor pointing after this entry
+func (e FeedEntry) Cursor() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(e.member()))
+}
+
+func parseFeedMember(member string) (FeedEntry, error) {
+	micros, id, ok := strings.Cut(member, ":")
+	if !ok {
+		return FeedEntry{}, ErrInvalidFeedCursor
+	}
+	n, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return FeedEntry{}, ErrInvalidFeedCursor
+	}
+	photoID, err := uuid.Parse(id)
+	if err != nil {
+		return FeedEntry{}, ErrInvalidFeedCursor
+	}
+	return FeedEntry{PhotoID: photoID, CreatedAt: time.UnixMicro(n)}, nil
+}
+
+// DecodeFeedCursor parses a cursor created by FeedEntry.Cursor
+func DecodeFeedCursor(cursor string) (FeedEntry, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return FeedEntry{}, ErrInvalidFeedCursor
+	}
+	return parseFeedMember(string(raw))
+}
+
+func feedKey(userID uuid.UUID) string {
+	return feedKeyPrefix + userID.String()
+}
+
+// fanOutScript adds a photo to every timeline that is ready and trims it to FeedTimelineSize.
+// Timelines that are not built yet are skipped, so a partial timeline is never served; their
+// generation is bumped so a rebuild running right now does not store a timeline without the photo.
+var fanOutScript = redis.NewScript(`
+for i, key in ipairs(KEYS) do
+	redis.call('INCR', key .. ARGV[4])
+	redis.call('EXPIRE', key .. ARGV[4], ARGV[5])
+	if redis.call('EXISTS', key .. ARGV[3]) == 1 then
+		redis.call('ZADD', key, 0, ARGV[1])
+		redis.call('ZREMRANGEBYRANK', key, 0, -(tonumber(ARGV[2]) + 1))
+	end
+end
+return 0
+`)
+
+// storeTimelineScript replaces a timeline (KEYS[1]) and marks it ready (KEYS[2]) only while its
+// generation (KEYS[3]) still equals ARGV[1]. ARGV[2] is the TTL in seconds, ARGV[3..] the members.
+var storeTimelineScript = redis.NewScript(`
+if (redis.call('GET', KEYS[3]) or '') ~= ARGV[1] then
+	return 0
+end
+redis.call('DEL', KEYS[1])
+for i = 3, #ARGV do
+	redis.call('ZADD', KEYS[1], 0, ARGV[i])
+end
+if #ARGV > 2 then
+	redis.call('EXPIRE', KEYS[1], ARGV[2])
+end
+redis.call('SET', KEYS[2], 1, 'EX', ARGV[2])
+return 1
+`)
+
+// FanOutPhoto pushes a new photo into the timelines of its owner and the owner's followers.
+func FanOutPhoto(db *gorm.DB, photo models.Photo) error {
+	rdb := database.GetRedis()
+	if rdb == nil {
+		return nil
+	}
+
+	var followerIDs []uuid.UUID
+	if err := db.Model(&models.Follow{}).
+		Where("following_id = ? AND status = ?", photo.UserID, models.FollowStatusAccepted).
+		Pluck("follower_id", &followerIDs).Error; err != nil {
+		return err
+	}
+
+	member := FeedEntry{PhotoID: photo.ID, CreatedAt: photo.CreatedAt}.member()
+	keys := []string{feedKey(photo.UserID)}
+	for _, id := range followerIDs {
+		keys = append(keys, feedKey(id))
+	}
+
+	// Batches keep a single script call short for accounts with many followers
+	ctx := context.Background()
+	for start := 0; start < len(keys); start += 1000 {
+		end := min(start+1000, len(keys))
+		if err := fanOutScript.Run(ctx, rdb, keys[start:end], member, FeedTimelineSize, feedReadySuffix,
+			feedGenerationSuffix, int(feedTimelineTTL.Seconds())).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InvalidateFeed drops the timelines of the users, e.g. after they followed or unfollowed someone.
+// The next read falls back to the database and rebuilds the timeline.
+func InvalidateFeed(userIDs ...uuid.UUID) error {
+	rdb := database.GetRedis()
+	if rdb == nil || len(userIDs) == 0 {
+		return nil
+	}
+	ctx := context.Background()
+	_, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range userIDs {
+			pipe.Del(ctx, feedKey(id), feedKey(id)+feedReadySuffix)
+			pipe.Incr(ctx, feedKey(id)+feedGenerationSuffix)
+			pipe.Expire(ctx, feedKey(id)+feedGenerationSuffix, feedTimelineTTL)
+		}
+		return nil
+	})
+	return err
+}
+
+// BeginFeedRebuild takes the user's rebuild lock and returns the timeline generation to pass to
+// StoreFeedTimeline. ok is false when another rebuild is already running.
+func BeginFeedRebuild(userID uuid.UUID) (generation string, ok bool, err error) {
+	rdb := database.GetRedis()
+	if rdb == nil {
+		return "", false, nil
+	}
+	ctx := context.Background()
+	key := feedKey(userID)
+
+	ok, err = rdb.SetNX(ctx, key+feedRebuildSuffix, 1, feedRebuildLockTTL).Result()
+	if err != nil || !ok {
+		return "", false, err
+	}
+	generation, err = rdb.Get(ctx, key+feedGenerationSuffix).Result()
+	if errors.Is(err, redis.Nil) {
+		err = nil
+	}
+	if err != nil {
+		EndFeedRebuild(userID)
+		return "", false, err
+	}
+	return generation, true, nil
+}
+
+// EndFeedRebuild releases the lock taken by BeginFeedRebuild.
+func EndFeedRebuild(userID uuid.UUID) {
+	if rdb := database.GetRedis(); rdb != nil {
+		rdb.Del(context.Background(), feedKey(userID)+feedRebuildSuffix)
+	}
+}
+
+// StoreFeedTimeline replaces the user's timeline with the given (newest) entries and marks it ready.
+// Nothing is stored when the timeline changed since BeginFeedRebuild returned generation: the
+// entries may miss that change, and the next read rebuilds the timeline again.
+func StoreFeedTimeline(userID uuid.UUID, generation string, entries []FeedEntry) error {
+	rdb := database.GetRedis()
+	if rdb == nil {
+		return nil
+	}
+	key := feedKey(userID)
+
+	args := make([]any, 0, len(entries)+2)
+	args = append(args, generation, int(feedTimelineTTL.Seconds()))
+	for _, e := range entries {
+		args = append(args, e.member())
+	}
+	return storeTimelineScript.Run(context.Background(), rdb,
+		[]string{key, key + feedReadySuffix, key + feedGenerationSuffix}, args...).Err()
+}
+
+// ReadFeedTimeline returns up to limit entries older than the cursor from the user's timeline.
+// ok is false when the timeline is not built or the page reaches past its trimmed end;
+// the caller must then use the database.
+func ReadFeedTimeline(userID uuid.UUID, cursor *FeedEntry, limit int) (entries []FeedEntry, ok bool, err error) {
+	rdb := database.GetRedis()
+	if rdb == nil {
+		return nil, false, nil
+	}
+	ctx := context.Background()
+	key := feedKey(userID)
+
+	ready, err := rdb.Exists(ctx, key+feedReadySuffix).Result()
+	if err != nil || ready == 0 {
+		return nil, false, err
+	}
+
+	maxLex := "+"
+	if cursor != nil {
+		maxLex = "(" + cursor.member()
+	}
+	members, err := rdb.ZRevRangeByLex(ctx, key, &redis.ZRangeBy{Max: maxLex, Min: "-", Count: int64(limit)}).Result()
+	if err != nil {
+		return nil, false, err
+	}
+
+	// A short page from a full timeline may miss older photos that were trimmed away
+	if len(members) < limit {
+		size, err := rdb.ZCard(ctx, key).Result()
+		if err != nil {
+			return nil, false, err
+		}
+		if size >= FeedTimelineSize {
+			return nil, false, nil
+		}
+	}
+
+	for _, m := range members {
+		entry, err := parseFeedMember(m)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, true, nil
+}
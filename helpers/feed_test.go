@@ -0,0 +1,31 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeedCursorRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	entry := FeedEntry{PhotoID: uuid.New(), CreatedAt: time.Now().Truncate(time.Microsecond)}
+	decoded, err := DecodeFeedCursor(entry.Cursor())
+	assert.NoError(t, err)
+	assert.Equal(t, entry.PhotoID, decoded.PhotoID)
+	assert.True(t, entry.CreatedAt.Equal(decoded.CreatedAt))
+
+	_, err = DecodeFeedCursor("not-a-cursor")
+	assert.ErrorIs(t, err, ErrInvalidFeedCursor)
+}
+
+func TestFeedMembersSortByTime(t *testing.T) {
+	t.Parallel()
+
+	// Timeline members are compared lexicographically, so they must sort like their timestamps
+	older := FeedEntry{PhotoID: uuid.New(), CreatedAt: time.UnixMicro(999_999)}
+	newer := FeedEntry{PhotoID: uuid.New(), CreatedAt: time.UnixMicro(1_000_000)}
+	assert.Less(t, older.member(), newer.member())
+}
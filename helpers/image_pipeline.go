@@ -0,0 +1,248 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register the WebP decoder for image.Decode
+)
+
+// Fixed variants produced for every uploaded photo
+const (
+	VariantThumbnail = "thumbnail" // square, center cropped
+	VariantMedium    = "medium"
+	VariantFull      = "full"
+
+	thumbnailSize   = 150
+	mediumMaxSize   = 640
+	fullMaxSize     = 1440
+	maxSourcePixels = 50_000_000 // decompression bomb guard
+	jpegQuality     = 85
+)
+
+var ErrImageTooManyPixels = errors.New("image dimensions are too large")
+
+// ImageVariant is an encoded variant of a photo
+type ImageVariant struct {
+	Name        string
+	Data        []byte
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+}
+
+// ProcessedImage is the result of ProcessImage
+type ProcessedImage struct {
+	Width    int // after applying the EXIF orientation
+	Height   int
+	Variants []ImageVariant
+}
+
+// ProcessImage decodes a JPEG, PNG or WebP image, applies its EXIF orientation and produces the
+// thumbnail, medium and full variants. The variants are re-encoded from pixels only, so EXIF
+// (GPS, camera, ...) and other metadata of the upload are not carried over.
+func ProcessImage(data []byte) (*ProcessedImage, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width*cfg.Height > maxSourcePixels {
+		return nil, ErrImageTooManyPixels
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	img := toRGBA(src)
+	if format == "jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	// PNG tetap PNG (transparansi), JPEG dan WebP disimpan sebagai JPEG
+	encode := encodeJPEG
+	contentType, ext := "image/jpeg", ".jpg"
+	if format == "png" {
+		encode = encodePNG
+		contentType, ext = "image/png", ".png"
+	}
+
+	result := &ProcessedImage{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+	for _, v := range []struct {
+		name string
+		img  image.Image
+	}{
+		{VariantThumbnail, squareThumbnail(img, thumbnailSize)},
+		{VariantMedium, fitWithin(img, mediumMaxSize)},
+		{VariantFull, fitWithin(img, fullMaxSize)},
+	} {
+		encoded, err := encode(v.img)
+		if err != nil {
+			return nil, err
+		}
+		result.Variants = append(result.Variants, ImageVariant{
+			Name:        v.name,
+			Data:        encoded,
+			ContentType: contentType,
+			Ext:         ext,
+			Width:       v.img.Bounds().Dx(),
+			Height:      v.img.Bounds().Dy(),
+		})
+	}
+	return result, nil
+}
+
+func encodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	return buf.Bytes(), err
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	return buf.Bytes(), err
+}
+
+func toRGBA(src image.Image) *image.RGBA {
+	if rgba, ok := src.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	return dst
+}
+
+// fitWithin scales the image down so its longest side is at most limit (never upscales)
+func fitWithin(img *image.RGBA, limit int) image.Image {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w <= limit && h <= limit {
+		return img
+	}
+	if w >= h {
+		h = max(1, h*limit/w)
+		w = limit
+	} else {
+		w = max(1, w*limit/h)
+		h = limit
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return dst
+}
+
+// squareThumbnail center-crops the image to a square and scales it to size x size
+func squareThumbnail(img *image.RGBA, size int) image.Image {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	side := min(w, h)
+	crop := image.Rect((w-side)/2, (h-side)/2, (w-side)/2+side, (h-side)/2+side)
+	size = min(size, side)
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Src, nil)
+	return dst
+}
+
+// jpegOrientation reads the EXIF orientation (1-8) of a JPEG; 1 when absent
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 { // start of scan / end of image
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+size]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+// exifOrientation reads tag 0x0112 from IFD0 of a TIFF structure
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for k := 0; k < entries; k++ {
+		entry := ifd + 2 + k*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if o := int(order.Uint16(tiff[entry+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// applyOrientation rotates / flips the image so it displays upright for the given EXIF orientation
+func applyOrientation(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirror horizontal
+				sx, sy = w-1-x, y
+			case 3: // rotate 180
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirror vertical
+				sx, sy = x, h-1-y
+			case 5: // transpose
+				sx, sy = y, x
+			case 6: // rotate 90 CW
+				sx, sy = y, h-1-x
+			case 7: // transverse
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotate 270 CW
+				sx, sy = w-1-y, x
+			}
+			si := src.PixOffset(sx, sy)
+			di := dst.PixOffset(x, y)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
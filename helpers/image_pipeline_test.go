@@ -0,0 +1,89 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withExifOrientation inserts an APP1 segment carrying a GPS-like marker and the orientation tag
+func withExifOrientation(t *testing.T, jpg []byte, orientation uint16) []byte {
+	t.Helper()
+
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1) // 1 entry
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	tiff = append(tiff, []byte("GPSSECRET")...)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func TestProcessImage_OrientsAndStripsExif(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for x := 0; x < 400; x++ {
+		for y := 0; y < 200; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x / 2), G: uint8(y), A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, img, nil))
+	data := withExifOrientation(t, buf.Bytes(), 6)
+	assert.Equal(t, 6, jpegOrientation(data))
+
+	result, err := ProcessImage(data)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, result.Width, "orientation 6 rotates the image")
+	assert.Equal(t, 400, result.Height)
+
+	sizes := map[string][2]int{}
+	for _, v := range result.Variants {
+		assert.Equal(t, "image/jpeg", v.ContentType)
+		assert.NotContains(t, string(v.Data), "Exif")
+		assert.NotContains(t, string(v.Data), "GPSSECRET")
+		sizes[v.Name] = [2]int{v.Width, v.Height}
+	}
+	assert.Equal(t, [2]int{150, 150}, sizes[VariantThumbnail])
+	assert.Equal(t, [2]int{200, 400}, sizes[VariantMedium], "smaller images are not upscaled")
+	assert.Equal(t, [2]int{200, 400}, sizes[VariantFull])
+}
+
+func TestProcessImage_PNGStaysPNG(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 2000, 1000))))
+
+	result, err := ProcessImage(buf.Bytes())
+	assert.NoError(t, err)
+	for _, v := range result.Variants {
+		assert.Equal(t, ".png", v.Ext)
+		_, format, err := image.DecodeConfig(bytes.NewReader(v.Data))
+		assert.NoError(t, err)
+		assert.Equal(t, "png", format)
+	}
+	assert.Equal(t, 640, result.Variants[1].Width)
+	assert.Equal(t, 320, result.Variants[1].Height)
+	assert.Equal(t, 1440, result.Variants[2].Width)
+
+	_, err = ProcessImage([]byte("not an image"))
+	assert.Error(t, err)
+}
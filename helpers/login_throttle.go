@@ -0,0 +1,182 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"mygram-api/database"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// loginFreeAttempts is the number of failed logins allowed before delays kick in
+	loginFreeAttempts = 2
+	loginBaseDelay    = time.Second
+	loginMaxDelay     = 30 * time.Second
+)
+
+// LoginThrottledError is returned while an email or IP has to wait before the next login attempt.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool // true when the attempts limit was reached, false for a progressive delay
+}
+
+func (e *LoginThrottledError) Error() string {
+	seconds := int(math.Ceil(e.RetryAfter.Seconds()))
+	if e.Locked {
+		return fmt.Sprintf("Too many failed login attempts. Try again in %d seconds.", seconds)
+	}
+	return fmt.Sprintf("Please wait %d seconds before trying to log in again.", seconds)
+}
+
+// LoginMaxFailedAttempts is the number of failed logins for one email before it is locked
+func LoginMaxFailedAttempts() int64 {
+	return envInt64("LOGIN_MAX_FAILED_ATTEMPTS", 5)
+}
+
+// loginMaxFailedAttemptsPerIP is higher than the per-email limit because many users can share one IP
+func loginMaxFailedAttemptsPerIP() int64 {
+	return envInt64("LOGIN_MAX_FAILED_ATTEMPTS_PER_IP", 50)
+}
+
+// MFAMaxAttempts is the number of codes one mfa_pending token may try before the login has to start over
+func MFAMaxAttempts() int64 {
+	return envInt64("MFA_MAX_ATTEMPTS", 5)
+}
+
+// LoginLockoutDuration is how long a lockout lasts; failures are also counted over this window
+func LoginLockoutDuration() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("LOGIN_LOCKOUT_DURATION")); err == nil && d > 0 {
+		return d
+	}
+	return 15 * time.Minute
+}
+
+func envInt64(key string, def int64) int64 {
+	if n, err := strconv.ParseInt(os.Getenv(key), 10, 64); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
+
+// loginBackoff returns the delay required after the given number of consecutive failures
+func loginBackoff(failures int64) time.Duration {
+	if failures <= loginFreeAttempts {
+		return 0
+	}
+	delay := loginBaseDelay << (failures - loginFreeAttempts - 1)
+	if delay <= 0 || delay > loginMaxDelay {
+		return loginMaxDelay
+	}
+	return delay
+}
+
+// incrWithExpiry increments a counter and starts its window on the first increment in one atomic step,
+// so a crash between INCR and PEXPIRE cannot leave a counter that never expires
+var incrWithExpiry = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return count`)
+
+type loginThrottleScope struct {
+	name  string
+	limit int64
+}
+
+func loginThrottleScopes(email, ip string) []loginThrottleScope {
+	return []loginThrottleScope{
+		{name: "email:" + strings.ToLower(strings.TrimSpace(email)), limit: LoginMaxFailedAttempts()},
+		{name: "ip:" + ip, limit: loginMaxFailedAttemptsPerIP()},
+	}
+}
+
+// CheckLoginThrottle returns a *LoginThrottledError when the email or IP is locked or still has to wait.
+// Without Redis the throttle is disabled.
+func CheckLoginThrottle(email, ip string) error {
+	rdb := database.GetRedis()
+	if rdb == nil {
+		return nil
+	}
+	ctx := context.Background()
+
+	for _, scope := range loginThrottleScopes(email, ip) {
+		ttl, err := rdb.PTTL(ctx, "auth:login_lock:"+scope.name).Result()
+		if err != nil {
+			return err
+		}
+		if ttl > 0 {
+			return &LoginThrottledError{RetryAfter: ttl, Locked: true}
+		}
+
+		ttl, err = rdb.PTTL(ctx, "auth:login_delay:"+scope.name).Result()
+		if err != nil {
+			return err
+		}
+		if ttl > 0 {
+			return &LoginThrottledError{RetryAfter: ttl}
+		}
+	}
+	return nil
+}
+
+// RecordLoginFailure counts a failed login for the email and IP. It returns true when this
+// failure locked the email, so the caller can notify the account owner once.
+func RecordLoginFailure(email, ip string) (bool, error) {
+	rdb := database.GetRedis()
+	if rdb == nil {
+		return false, nil
+	}
+	ctx := context.Background()
+	window := LoginLockoutDuration()
+	emailLocked := false
+
+	for i, scope := range loginThrottleScopes(email, ip) {
+		failKey := "auth:login_fail:" + scope.name
+
+		count, err := incrWithExpiry.Run(ctx, rdb, []string{failKey}, window.Milliseconds()).Int64()
+		if err != nil {
+			return false, err
+		}
+
+		if count >= scope.limit {
+			if err := rdb.Set(ctx, "auth:login_lock:"+scope.name, 1, window).Err(); err != nil {
+				return false, err
+			}
+			rdb.Del(ctx, failKey, "auth:login_delay:"+scope.name)
+			emailLocked = emailLocked || i == 0
+			continue
+		}
+
+		if delay := loginBackoff(count); delay > 0 {
+			if err := rdb.Set(ctx, "auth:login_delay:"+scope.name, 1, delay).Err(); err != nil {
+				return false, err
+			}
+		}
+	}
+	return emailLocked, nil
+}
+
+// ResetLoginFailures clears the failure counter of an email after a successful login
+func ResetLoginFailures(email string) error {
+	rdb := database.GetRedis()
+	if rdb == nil {
+		return nil
+	}
+	return rdb.Del(context.Background(), loginFailureResetKeys(email)...).Err()
+}
+
+// loginFailureResetKeys are the keys cleared by a successful login. Only the email's counter and delay
+// are reset; the IP counter keeps counting until its window ends, otherwise an attacker could log in to
+// their own account between guesses and never reach the per-IP limit.
+func loginFailureResetKeys(email string) []string {
+	scope := loginThrottleScopes(email, "")[0]
+	return []string{"auth:login_fail:" + scope.name, "auth:login_delay:" + scope.name}
+}
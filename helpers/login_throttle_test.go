@@ -0,0 +1,30 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginBackoff(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, time.Duration(0), loginBackoff(1))
+	assert.Equal(t, time.Duration(0), loginBackoff(2))
+	assert.Equal(t, time.Second, loginBackoff(3))
+	assert.Equal(t, 2*time.Second, loginBackoff(4))
+	assert.Equal(t, 4*time.Second, loginBackoff(5))
+	assert.Equal(t, loginMaxDelay, loginBackoff(20))
+	assert.Equal(t, loginMaxDelay, loginBackoff(200))
+}
+
+func TestLoginFailureResetKeys_KeepsIPCounter(t *testing.T) {
+	t.Parallel()
+
+	keys := loginFailureResetKeys(" User@Example.com ")
+	assert.Equal(t, []string{"auth:login_fail:email:user@example.com", "auth:login_delay:email:user@example.com"}, keys)
+	for _, key := range keys {
+		assert.NotContains(t, key, ":ip:")
+	}
+}
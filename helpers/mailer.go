@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"html/template"
 	"log"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-mail/mail/v2"
 )
@@ -34,6 +37,8 @@ type EmailTemplateData struct {
 	Subject   string
 	Name      string
 	Email     string
+	ActionURL string // link for emails that ask the user to do something (reset password, ...)
+	Duration  string // e.g. how long an account stays locked
 	// add more fields if templates need them
 }
 
@@ -59,7 +64,11 @@ func InitEmailTemplates() error {
 
 		// Map template key -> file path
 		templateFiles := map[string]string{
-			"welcome": filepath.Join(templatesDir, "welcome-email.html"),
+			"welcome":          filepath.Join(templatesDir, "welcome-email.html"),
+			"password-reset":   filepath.Join(templatesDir, "password-reset.html"),
+			"verify-email":     filepath.Join(templatesDir, "verify-email.html"),
+			"password-changed": filepath.Join(templatesDir, "password-changed.html"),
+			"account-locked":   filepath.Join(templatesDir, "account-locked.html"),
 			// add other templates here
 		}
 
@@ -135,6 +144,14 @@ func SendTemplatedEmail(toEmail, subject, templateName string, data EmailTemplat
 }
 
 func sendPlainWelcomeEmailSync(recipientEmail, username string) error {
+	// Keep subject identical to original test expectation
+	subject := "Welcome to MyGram!"
+	body := fmt.Sprintf("Hello %s,\n\nWelcome to MyGram! We're excited to have you.", username)
+	return sendPlainEmailSync(recipientEmail, subject, body)
+}
+
+// sendPlainEmailSync sends a plain-text email (used as fallback when templates are unavailable)
+func sendPlainEmailSync(recipientEmail, subject, body string) error {
 	m := NewMessageFactory()
 	from := os.Getenv("MAIL_FROM_ADDRESS")
 	if from == "" {
@@ -142,11 +159,7 @@ func sendPlainWelcomeEmailSync(recipientEmail, username string) error {
 	}
 	m.SetHeader("From", from)
 	m.SetHeader("To", recipientEmail)
-	// Keep subject identical to original test expectation
-	subject := "Welcome to MyGram!"
 	m.SetHeader("Subject", subject)
-
-	body := fmt.Sprintf("Hello %s,\n\nWelcome to MyGram! We're excited to have you.", username)
 	m.SetBody("text/plain", body)
 
 	host := os.Getenv("MAIL_HOST")
@@ -158,6 +171,55 @@ func sendPlainWelcomeEmailSync(recipientEmail, username string) error {
 	return d.DialAndSend(m)
 }
 
+// sendEmailWithFallback sends a templated email, falling back to the plain-text body on error
+func sendEmailWithFallback(recipientEmail, templateName string, data EmailTemplateData, plainBody string) error {
+	if emailTemplateService == nil {
+		log.Printf("Email templates not initialized; falling back to plain-text %s email.", templateName)
+		return sendPlainEmailSync(recipientEmail, data.Subject, plainBody)
+	}
+	if err := SendTemplatedEmail(recipientEmail, data.Subject, templateName, data); err != nil {
+		log.Printf("Failed to send templated %s email: %v. Falling back to plain-text.", templateName, err)
+		return sendPlainEmailSync(recipientEmail, data.Subject, plainBody)
+	}
+	return nil
+}
+
+// newEmailTemplateData fills the common template fields from the environment
+func newEmailTemplateData(subject, name, email string) EmailTemplateData {
+	appName := os.Getenv("MAIL_FROM_NAME")
+	if appName == "" {
+		appName = "MyGram"
+	}
+	return EmailTemplateData{
+		AppName:   appName,
+		AppDomain: os.Getenv("APP_DOMAIN"),
+		Subject:   subject,
+		Name:      name,
+		Email:     email,
+	}
+}
+
+// sendEmailAsync runs send in a goroutine and logs the outcome
+func sendEmailAsync(kind, recipientEmail string, send func() error) {
+	go func() {
+		if err := send(); err != nil {
+			log.Printf("Could not send %s email to %s: %v", kind, recipientEmail, err)
+		} else {
+			log.Printf("Successfully sent %s email to %s", kind, recipientEmail)
+		}
+	}()
+}
+
+// BuildActionURL returns the link put in emails, e.g. <PASSWORD_RESET_URL>?token=...
+// When the env variable is empty the link points to defaultPath on APP_URL.
+func BuildActionURL(envKey, defaultPath, token string) string {
+	base := os.Getenv(envKey)
+	if base == "" {
+		base = strings.TrimRight(os.Getenv("APP_URL"), "/") + defaultPath
+	}
+	return base + "?token=" + url.QueryEscape(token)
+}
+
 func SendWelcomeEmailWithTemplate(recipientEmail, username string) error {
 	// Attempt to init templates; if it fails -> fallback immediately to plain-text
 	if emailTemplateService == nil {
@@ -199,3 +261,46 @@ func SendWelcomeEmail(recipientEmail, username string) {
 		}
 	}()
 }
+
+// SendPasswordResetEmail sends the password reset link to the user in a goroutine
+func SendPasswordResetEmail(recipientEmail, username, resetURL string) {
+	data := newEmailTemplateData("Reset your MyGram password", username, recipientEmail)
+	data.ActionURL = resetURL
+	body := fmt.Sprintf("Hello %s,\n\nWe received a request to reset your MyGram password. Open the link below to choose a new one:\n\n%s\n\nIf you did not request this, you can ignore this email.", username, resetURL)
+
+	sendEmailAsync("password reset", recipientEmail, func() error {
+		return sendEmailWithFallback(recipientEmail, "password-reset", data, body)
+	})
+}
+
+// SendVerificationEmail sends the email address confirmation link in a goroutine
+func SendVerificationEmail(recipientEmail, username, verifyURL string) {
+	data := newEmailTemplateData("Confirm your email address", username, recipientEmail)
+	data.ActionURL = verifyURL
+	body := fmt.Sprintf("Hello %s,\n\nPlease confirm that %s is your email address by opening the link below:\n\n%s\n\nIf you did not request this, you can ignore this email.", username, recipientEmail, verifyURL)
+
+	sendEmailAsync("verification", recipientEmail, func() error {
+		return sendEmailWithFallback(recipientEmail, "verify-email", data, body)
+	})
+}
+
+// SendPasswordChangedEmail notifies the user that their password was changed, in a goroutine
+func SendPasswordChangedEmail(recipientEmail, username string) {
+	data := newEmailTemplateData("Your MyGram password was changed", username, recipientEmail)
+	body := fmt.Sprintf("Hello %s,\n\nThe password of your MyGram account was just changed and your other sessions were logged out.\n\nIf this wasn't you, reset your password immediately.", username)
+
+	sendEmailAsync("password changed", recipientEmail, func() error {
+		return sendEmailWithFallback(recipientEmail, "password-changed", data, body)
+	})
+}
+
+// SendAccountLockedEmail tells the user that login was locked after too many failed attempts, in a goroutine
+func SendAccountLockedEmail(recipientEmail, username string, duration time.Duration) {
+	data := newEmailTemplateData("Your MyGram account was temporarily locked", username, recipientEmail)
+	data.Duration = duration.String()
+	body := fmt.Sprintf("Hello %s,\n\nWe locked logins to your MyGram account for %s after too many failed login attempts.\n\nIf this wasn't you, consider changing your password once the lock expires.", username, data.Duration)
+
+	sendEmailAsync("account locked", recipientEmail, func() error {
+		return sendEmailWithFallback(recipientEmail, "account-locked", data, body)
+	})
+}
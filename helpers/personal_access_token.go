@@ -0,0 +1,75 @@
+package helpers
+
+import (
+	"errors"
+	"mygram-api/models"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// PersonalAccessTokenPrefix marks bearer tokens that are personal access tokens instead of JWTs
+	PersonalAccessTokenPrefix = "mgp_"
+	// TokenTypePersonalAccess is the "typ" put in userData for requests authenticated with a personal access token
+	TokenTypePersonalAccess = "personal_access_token"
+)
+
+// PersonalAccessTokenScopes lists the scopes a personal access token can be granted.
+var PersonalAccessTokenScopes = []string{
+	"users:read",
+	"photos:read",
+	"photos:write",
+	"comments:read",
+	"comments:write",
+	"socialmedias:read",
+	"socialmedias:write",
+}
+
+var ErrPersonalAccessTokenInvalid = errors.New("invalid, expired or revoked personal access token")
+
+// IsValidScope reports whether scope can be granted to a personal access token.
+func IsValidScope(scope string) bool {
+	return slices.Contains(PersonalAccessTokenScopes, scope)
+}
+
+// NewPersonalAccessToken returns a new raw token with the personal access token prefix.
+func NewPersonalAccessToken() (string, error) {
+	raw, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	return PersonalAccessTokenPrefix + raw, nil
+}
+
+// AuthenticatePersonalAccessToken looks up an active token and its owner.
+// LastUsedAt is refreshed at most once a minute to keep authentication cheap.
+func AuthenticatePersonalAccessToken(db *gorm.DB, raw string) (*models.PersonalAccessToken, error) {
+	var pat models.PersonalAccessToken
+	if err := db.Preload("User").Where("token_hash = ?", HashOpaqueToken(raw)).First(&pat).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPersonalAccessTokenInvalid
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if pat.RevokedAt != nil || (pat.ExpiresAt != nil && now.After(*pat.ExpiresAt)) || pat.User == nil {
+		return nil, ErrPersonalAccessTokenInvalid
+	}
+
+	if pat.LastUsedAt == nil || now.Sub(*pat.LastUsedAt) > time.Minute {
+		db.Model(&models.PersonalAccessToken{}).Where("id = ?", pat.ID).Update("last_used_at", now)
+	}
+	return &pat, nil
+}
+
+// SplitScopes turns the stored comma separated scopes into a slice.
+func SplitScopes(scopes string) []string {
+	if scopes == "" {
+		return []string{}
+	}
+	return strings.Split(scopes, ",")
+}
@@ -0,0 +1,183 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mygram-api/models"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// photoQueueSize bounds the photos waiting for a worker (and so the memory they can take)
+	photoQueueSize = 1000
+	// photoRescanInterval is how often photos still processing are looked up again: photos that did
+	// not fit into the queue, and photos left over by a restart or by another app instance
+	photoRescanInterval = time.Minute
+)
+
+var (
+	// photoJobs is the queue of photos waiting for the image pipeline; nil until StartPhotoProcessor
+	photoJobs chan uuid.UUID
+	// queuedPhotos holds the IDs in photoJobs or being processed, so a rescan does not add them twice
+	queuedPhotos sync.Map
+)
+
+// StartPhotoProcessor starts the background image pipeline workers and a rescan that queues photos
+// still processing, right away (photos left over when the app stopped) and then every photoRescanInterval.
+func StartPhotoProcessor(db *gorm.DB, workers int) {
+	photoJobs = make(chan uuid.UUID, photoQueueSize)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for id := range photoJobs {
+				if err := ProcessPhoto(db, id); err != nil {
+					log.Printf("Failed to process photo %s: %v", id, err)
+				}
+				queuedPhotos.Delete(id)
+			}
+		}()
+	}
+
+	go func() {
+		for {
+			requeueProcessingPhotos(db)
+			time.Sleep(photoRescanInterval)
+		}
+	}()
+}
+
+// requeueProcessingPhotos queues photos that are processing since before the last rescan
+// and are not queued yet. Newer ones are most likely still queued by the instance that created them.
+func requeueProcessingPhotos(db *gorm.DB) {
+	var pending []uuid.UUID
+	if err := db.Model(&models.Photo{}).
+		Where("status = ? AND updated_at < ?", models.PhotoStatusProcessing, time.Now().Add(-photoRescanInterval)).
+		Order("updated_at").
+		Limit(photoQueueSize).
+		Pluck("id", &pending).Error; err != nil {
+		log.Printf("Failed to load unprocessed photos: %v", err)
+		return
+	}
+	for _, id := range pending {
+		EnqueuePhotoProcessing(db, id)
+	}
+}
+
+// EnqueuePhotoProcessing schedules the image pipeline for a photo without blocking the request.
+// When the queue is full the photo stays processing and the next rescan picks it up.
+func EnqueuePhotoProcessing(db *gorm.DB, photoID uuid.UUID) {
+	if photoJobs == nil {
+		// Tanpa worker (mis. di test) diproses langsung di goroutine sendiri
+		go func() {
+			if err := ProcessPhoto(db, photoID); err != nil {
+				log.Printf("Failed to process photo %s: %v", photoID, err)
+			}
+		}()
+		return
+	}
+
+	if _, queued := queuedPhotos.LoadOrStore(photoID, struct{}{}); queued {
+		return
+	}
+	select {
+	case photoJobs <- photoID:
+	default:
+		queuedPhotos.Delete(photoID)
+		log.Printf("Photo processing queue is full, photo %s waits for the next rescan", photoID)
+	}
+}
+
+// ProcessPhoto creates the variants of an uploaded photo, records them on the photo and removes the
+// original upload (which may still contain EXIF metadata such as GPS coordinates).
+func ProcessPhoto(db *gorm.DB, photoID uuid.UUID) error {
+	store := GetStorage()
+	if store == nil {
+		return errors.New("storage is not configured")
+	}
+	ctx := context.Background()
+
+	var photo models.Photo
+	if err := db.First(&photo, "id = ?", photoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil // sudah dihapus
+		}
+		return err
+	}
+	if photo.Status != models.PhotoStatusProcessing || photo.StorageKey == "" {
+		return nil
+	}
+
+	data, err := store.Get(ctx, photo.StorageKey)
+	if err == nil {
+		var processed *ProcessedImage
+		if processed, err = ProcessImage(data); err == nil {
+			return storeVariants(ctx, db, store, photo, processed)
+		}
+	}
+
+	// File tidak bisa dibaca / di-decode: tandai gagal supaya tidak diproses ulang terus-menerus
+	// (kecuali file-nya sudah diganti upload baru selama diproses)
+	if markErr := db.Model(&models.Photo{}).
+		Where("id = ? AND status = ? AND storage_key = ?", photo.ID, models.PhotoStatusProcessing, photo.StorageKey).
+		Update("status", models.PhotoStatusFailed).Error; markErr != nil {
+		log.Printf("Failed to mark photo %s as failed: %v", photo.ID, markErr)
+	}
+	return err
+}
+
+func storeVariants(ctx context.Context, db *gorm.DB, store Storage, photo models.Photo, processed *ProcessedImage) error {
+	// Every run writes under its own prefix, so a run that lost the race (photo replaced meanwhile)
+	// never overwrites or deletes the variants of the run that won
+	runID := uuid.NewString()
+	urls := map[string]string{}
+	var keys []string
+	for _, v := range processed.Variants {
+		key := fmt.Sprintf("photos/%s/%s/%s/%s%s", photo.UserID, photo.ID, runID, v.Name, v.Ext)
+		url, err := store.Put(ctx, key, v.Data, v.ContentType)
+		if err != nil {
+			deleteKeys(ctx, store, keys)
+			return err
+		}
+		urls[v.Name] = url
+		keys = append(keys, key)
+	}
+
+	result := db.Model(&models.Photo{}).
+		Where("id = ? AND status = ? AND storage_key = ?", photo.ID, models.PhotoStatusProcessing, photo.StorageKey).
+		Updates(map[string]any{
+			"status":        models.PhotoStatusReady,
+			"photo_url":     urls[VariantFull],
+			"thumbnail_url": urls[VariantThumbnail],
+			"medium_url":    urls[VariantMedium],
+			"width":         processed.Width,
+			"height":        processed.Height,
+			"variant_keys":  strings.Join(keys, ","),
+			"storage_key":   "",
+		})
+	if result.Error != nil {
+		deleteKeys(ctx, store, keys)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Foto dihapus / diganti selama diproses
+		deleteKeys(ctx, store, keys)
+		return nil
+	}
+
+	deleteKeys(ctx, store, []string{photo.StorageKey})
+	return nil
+}
+
+func deleteKeys(ctx context.Context, store Storage, keys []string) {
+	for _, key := range keys {
+		if err := store.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete stored file %s: %v", key, err)
+		}
+	}
+}
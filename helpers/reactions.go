@@ -0,0 +1,33 @@
+package helpers
+
+import (
+	"os"
+	"slices"
+	"strings"
+)
+
+// defaultCommentReactions is used when COMMENT_REACTIONS is not set
+var defaultCommentReactions = []string{"like", "love", "haha", "wow", "sad", "angry"}
+
+// CommentReactionTypes returns the allowed comment reactions, configured as a comma separated COMMENT_REACTIONS
+func CommentReactionTypes() []string {
+	raw := os.Getenv("COMMENT_REACTIONS")
+	if raw == "" {
+		return defaultCommentReactions
+	}
+	var types []string
+	for _, t := range strings.Split(raw, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" && !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		return defaultCommentReactions
+	}
+	return types
+}
+
+// IsValidCommentReaction reports whether t is one of CommentReactionTypes
+func IsValidCommentReaction(t string) bool {
+	return slices.Contains(CommentReactionTypes(), t)
+}
@@ -0,0 +1,17 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommentReactionTypesFromEnv(t *testing.T) {
+	t.Setenv("COMMENT_REACTIONS", " Fire, clap,,fire ")
+	assert.Equal(t, []string{"fire", "clap"}, CommentReactionTypes())
+	assert.True(t, IsValidCommentReaction("clap"))
+	assert.False(t, IsValidCommentReaction("like"))
+
+	t.Setenv("COMMENT_REACTIONS", "")
+	assert.True(t, IsValidCommentReaction("like"))
+}
@@ -0,0 +1,127 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"mygram-api/models"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+	ErrRefreshTokenExpired = errors.New("refresh token has expired")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used, the session has been revoked")
+)
+
+// RefreshTokenTTL returns the lifetime of refresh tokens.
+// It can be configured with JWT_REFRESH_TOKEN_TTL (e.g. "720h"), default 30 days.
+func RefreshTokenTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("JWT_REFRESH_TOKEN_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return 30 * 24 * time.Hour
+}
+
+// GenerateOpaqueToken returns a random, URL-safe token string.
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashOpaqueToken returns the SHA-256 hex digest used to store opaque tokens.
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateRefreshToken stores a new refresh token in the given family and returns the raw token.
+func CreateRefreshToken(db *gorm.DB, userID, familyID uuid.UUID) (string, error) {
+	raw, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	rt := models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: HashOpaqueToken(raw),
+		ExpiresAt: time.Now().Add(RefreshTokenTTL()),
+	}
+	if err := db.Create(&rt).Error; err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// RotateRefreshToken consumes a refresh token and issues its successor in the same family.
+// Presenting a token that was already used revokes the whole family, because it means
+// the token has been copied by someone else; in that case the consumed token record is
+// still returned together with ErrRefreshTokenReused so callers can log the incident.
+func RotateRefreshToken(db *gorm.DB, raw string) (*models.RefreshToken, string, error) {
+	var rt models.RefreshToken
+	if err := db.Where("token_hash = ?", HashOpaqueToken(raw)).First(&rt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrRefreshTokenInvalid
+		}
+		return nil, "", err
+	}
+
+	if rt.RevokedAt != nil {
+		return nil, "", ErrRefreshTokenInvalid
+	}
+	if rt.UsedAt != nil {
+		if err := RevokeRefreshTokenFamily(db, rt.FamilyID); err != nil {
+			return nil, "", err
+		}
+		return &rt, "", ErrRefreshTokenReused
+	}
+	if time.Now().After(rt.ExpiresAt) {
+		return nil, "", ErrRefreshTokenExpired
+	}
+
+	var newRaw string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Conditional update so two concurrent requests cannot both consume the same token
+		res := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", rt.ID).
+			Update("used_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+
+		var err error
+		newRaw, err = CreateRefreshToken(tx, rt.UserID, rt.FamilyID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			if revokeErr := RevokeRefreshTokenFamily(db, rt.FamilyID); revokeErr != nil {
+				return nil, "", revokeErr
+			}
+			return &rt, "", err
+		}
+		return nil, "", err
+	}
+
+	return &rt, newRaw, nil
+}
+
+// RevokeRefreshTokenFamily revokes every refresh token of a login session.
+func RevokeRefreshTokenFamily(db *gorm.DB, familyID uuid.UUID) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
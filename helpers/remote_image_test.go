@@ -0,0 +1,96 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchRemoteImage(t *testing.T) {
+	var pngData bytes.Buffer
+	assert.NoError(t, png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 4, 4))))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/photo.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain") // header server diabaikan, isi yang dicek
+		w.Write(pngData.Bytes())
+	})
+	mux.HandleFunc("/page.html", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body>not an image</body></html>"))
+	})
+	mux.HandleFunc("/fake.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...))
+	})
+	mux.HandleFunc("/big.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(pngData.Bytes())
+		w.Write(make([]byte, 4096))
+	})
+	mux.HandleFunc("/slow.png", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		w.Write(pngData.Bytes())
+	})
+	mux.HandleFunc("/missing.png", http.NotFound)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	t.Setenv("PHOTO_MAX_UPLOAD_SIZE", "2048")
+	t.Setenv("PHOTO_IMPORT_TIMEOUT", "200ms")
+	ctx := context.Background()
+
+	// 1. httptest listens on loopback, which is blocked unless explicitly allowed
+	_, _, _, err := fetchRemoteImage(ctx, srv.URL+"/photo.png", false)
+	assert.ErrorIs(t, err, ErrRemoteHostNotAllowed)
+
+	data, contentType, ext, err := fetchRemoteImage(ctx, srv.URL+"/photo.png", true)
+	assert.NoError(t, err)
+	assert.Equal(t, pngData.Bytes(), data)
+	assert.Equal(t, "image/png", contentType)
+	assert.Equal(t, ".png", ext)
+
+	// 2. Content must be a decodable image within the size cap
+	_, _, _, err = fetchRemoteImage(ctx, srv.URL+"/page.html", true)
+	assert.ErrorIs(t, err, ErrUnsupportedImageType)
+	_, _, _, err = fetchRemoteImage(ctx, srv.URL+"/fake.png", true)
+	assert.ErrorIs(t, err, ErrUnsupportedImageType)
+	_, _, _, err = fetchRemoteImage(ctx, srv.URL+"/big.png", true)
+	assert.ErrorIs(t, err, ErrImageTooLarge)
+
+	// 3. Timeouts, error statuses and non-http schemes
+	_, _, _, err = fetchRemoteImage(ctx, srv.URL+"/slow.png", true)
+	assert.ErrorIs(t, err, ErrRemoteImageFetch)
+	_, _, _, err = fetchRemoteImage(ctx, srv.URL+"/missing.png", true)
+	assert.ErrorIs(t, err, ErrRemoteImageFetch)
+	_, _, _, err = fetchRemoteImage(ctx, "file:///etc/passwd", true)
+	assert.ErrorIs(t, err, ErrRemoteImageFetch)
+}
+
+func TestIsBlockedImportAddr(t *testing.T) {
+	t.Parallel()
+
+	for addr, blocked := range map[string]bool{
+		"127.0.0.1":        true,
+		"10.1.2.3":         true,
+		"172.16.0.1":       true,
+		"192.168.1.1":      true,
+		"169.254.169.254":  true, // cloud metadata
+		"100.64.0.1":       true,
+		"0.0.0.0":          true,
+		"::1":              true,
+		"fd00::1":          true,
+		"fe80::1":          true,
+		"::ffff:127.0.0.1": true,
+		"64:ff9b::a00:1":   true,
+		"93.184.216.34":    false,
+		"2606:4700::1111":  false,
+	} {
+		assert.Equal(t, blocked, isBlockedImportAddr(netip.MustParseAddr(addr)), addr)
+	}
+}
@@ -0,0 +1,71 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"mygram-api/models"
+	"os"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Roles lists every valid user role
+var Roles = []string{models.RoleUser, models.RoleModerator, models.RoleAdmin}
+
+// IsValidRole reports whether role is one of Roles
+func IsValidRole(role string) bool {
+	return slices.Contains(Roles, role)
+}
+
+// CanModerate reports whether the role may act on other users' content
+func CanModerate(role string) bool {
+	return role == models.RoleModerator || role == models.RoleAdmin
+}
+
+// RecordAudit writes a privileged action of the authenticated user to the audit log.
+// Actor, method and path are taken from the request.
+func RecordAudit(c *gin.Context, db *gorm.DB, entry models.AuditLog) error {
+	userData := c.MustGet("userData").(map[string]any)
+	entry.ActorID, _ = uuid.Parse(userData["id"].(string))
+	entry.ActorRole, _ = userData["role"].(string)
+	entry.Method = c.Request.Method
+	entry.Path = c.Request.URL.Path
+	return db.Create(&entry).Error
+}
+
+// BootstrapAdmin promotes the account with ADMIN_EMAIL to admin while no admin exists yet, so the
+// first admin can be created without database access. Later role changes go through the admin API.
+// The account must be registered and have verified its email; it gets the role with its next login.
+func BootstrapAdmin(db *gorm.DB) (bool, error) {
+	email := os.Getenv("ADMIN_EMAIL")
+	if email == "" {
+		return false, nil
+	}
+
+	var admins int64
+	if err := db.Model(&models.User{}).Where("role = ?", models.RoleAdmin).Count(&admins).Error; err != nil {
+		return false, err
+	}
+	if admins > 0 {
+		return false, nil
+	}
+
+	var user models.User
+	if err := db.First(&user, "email = ?", email).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, fmt.Errorf("ADMIN_EMAIL %s is not registered yet", email)
+		}
+		return false, err
+	}
+	// Tanpa verifikasi, siapa saja yang lebih dulu mendaftar dengan email ini akan jadi admin
+	if user.EmailVerifiedAt == nil {
+		return false, fmt.Errorf("ADMIN_EMAIL %s has not verified its email yet", email)
+	}
+	if err := db.Model(&user).Update("role", models.RoleAdmin).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,147 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"mygram-api/database"
+	"mygram-api/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// revokedSessionKeyPrefix + session ID: sessions revoked while their access tokens may still be valid
+	revokedSessionKeyPrefix = "auth:session_revoked:"
+	// sessionSeenKeyPrefix + session ID: present while last_seen_at was updated recently
+	sessionSeenKeyPrefix = "auth:session_seen:"
+	// sessionTouchInterval limits last_seen_at writes to one per session per interval
+	sessionTouchInterval = time.Minute
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionRevoked  = errors.New("session has been revoked")
+)
+
+// CreateSession records a new login session for the user.
+func CreateSession(db *gorm.DB, userID uuid.UUID, userAgent, ipAddress string) (models.Session, error) {
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+	session := models.Session{
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		LastSeenAt: time.Now(),
+	}
+	err := db.Create(&session).Error
+	return session, err
+}
+
+// ListActiveSessions returns the sessions of the user that can still be used, most recently active first:
+// not revoked and holding an unused, unexpired refresh token. The current session (uuid.Nil for tokens
+// without one) is always included, its access token is still valid even when the refresh token expired.
+func ListActiveSessions(db *gorm.DB, userID, currentSessionID uuid.UUID) ([]models.Session, error) {
+	refreshable := db.Model(&models.RefreshToken{}).
+		Select("1").
+		Where("refresh_tokens.family_id = sessions.id AND refresh_tokens.used_at IS NULL AND refresh_tokens.revoked_at IS NULL AND refresh_tokens.expires_at > ?", time.Now())
+
+	var sessions []models.Session
+	err := db.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Where(db.Where("id = ?", currentSessionID).Or("EXISTS (?)", refreshable)).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// RevokeSession revokes one session of the user together with its refresh tokens.
+// Its access tokens are rejected by CheckSession from now on.
+func RevokeSession(db *gorm.DB, userID, sessionID uuid.UUID) error {
+	result := db.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+
+	if err := RevokeRefreshTokenFamily(db, sessionID); err != nil {
+		return err
+	}
+
+	return markSessionsRevoked(sessionID)
+}
+
+// markSessionsRevoked stores the revoked marker that CheckSession reads for each session.
+func markSessionsRevoked(sessionIDs ...uuid.UUID) error {
+	rdb := database.GetRedis()
+	if rdb == nil || len(sessionIDs) == 0 {
+		return nil
+	}
+
+	// Access tokens of the session expire after AccessTokenTTL, so the marker can expire too
+	pipe := rdb.Pipeline()
+	for _, sessionID := range sessionIDs {
+		pipe.Set(context.Background(), revokedSessionKeyPrefix+sessionID.String(), 1, AccessTokenTTL())
+	}
+	_, err := pipe.Exec(context.Background())
+	return err
+}
+
+// CheckSession returns ErrSessionRevoked when the session of an access token was revoked,
+// and updates its last_seen_at at most once per sessionTouchInterval.
+// With Redis most requests need no database query at all.
+func CheckSession(db *gorm.DB, sessionID uuid.UUID) error {
+	now := time.Now()
+
+	if rdb := database.GetRedis(); rdb != nil {
+		ctx := context.Background()
+		n, err := rdb.Exists(ctx, revokedSessionKeyPrefix+sessionID.String()).Result()
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrSessionRevoked
+		}
+
+		// Seen recently: nothing to update
+		fresh, err := rdb.SetNX(ctx, sessionSeenKeyPrefix+sessionID.String(), 1, sessionTouchInterval).Result()
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return nil
+		}
+
+		result := db.Model(&models.Session{}).
+			Where("id = ? AND revoked_at IS NULL", sessionID).
+			Update("last_seen_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrSessionRevoked
+		}
+		return nil
+	}
+
+	// Without Redis: read the session and only write when last_seen_at is stale
+	var session models.Session
+	if err := db.Select("id", "revoked_at", "last_seen_at").First(&session, "id = ?", sessionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+	if session.RevokedAt != nil {
+		return ErrSessionRevoked
+	}
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		return db.Model(&session).Update("last_seen_at", now).Error
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package helpers
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingKey is one private key from JWT_KEY_DIR, identified by its kid (file name without .pem)
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	modTime time.Time
+}
+
+// signingKeySet holds every key that is still valid for verification and the one used for signing
+type signingKeySet struct {
+	active *signingKey
+	keys   map[string]*signingKey
+}
+
+// JWKSCacheMaxAge is how long clients may cache /.well-known/jwks.json. A new key only starts
+// signing once it is older than this, so every cached JWKS already contains it.
+const JWKSCacheMaxAge = 5 * time.Minute
+
+var (
+	signingKeysMu sync.RWMutex
+	// signingKeys is nil when no key directory is configured: tokens are then signed with HS256 and JWT_SECRET_KEY
+	signingKeys *signingKeySet
+)
+
+// InitSigningKeys loads the asymmetric signing keys from JWT_KEY_DIR, if configured.
+//
+// Key rotation: add a new <kid>.pem to the directory. It is published in the JWKS as soon as it is
+// loaded and becomes the signing key once its file is older than JWKSCacheMaxAge (the newest such
+// file wins, or the one named by JWT_ACTIVE_KID). Older keys keep verifying tokens until their file
+// is removed, which should happen after AccessTokenTTL has passed.
+func InitSigningKeys() error {
+	dir := os.Getenv("JWT_KEY_DIR")
+	if dir == "" {
+		log.Println("JWT_KEY_DIR is not set; signing tokens with HS256 and JWT_SECRET_KEY.")
+		return nil
+	}
+	return LoadSigningKeys(dir)
+}
+
+// StartSigningKeyReloader re-reads JWT_KEY_DIR periodically so keys can be rotated without a restart.
+func StartSigningKeyReloader(interval time.Duration) {
+	dir := os.Getenv("JWT_KEY_DIR")
+	if dir == "" {
+		return
+	}
+	go func() {
+		for range time.Tick(interval) {
+			if err := LoadSigningKeys(dir); err != nil {
+				log.Printf("Failed to reload JWT signing keys: %v", err)
+			}
+		}
+	}()
+}
+
+// LoadSigningKeys reads every <kid>.pem private key (RSA or Ed25519) from dir.
+// On error the previously loaded keys are kept.
+func LoadSigningKeys(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	set := &signingKeySet{keys: map[string]*signingKey{}}
+	publishedBefore := time.Now().Add(-JWKSCacheMaxAge)
+	var newest *signingKey
+	for _, path := range files {
+		key, err := loadSigningKey(path)
+		if err != nil {
+			return fmt.Errorf("failed to load signing key %s: %v", path, err)
+		}
+		set.keys[key.kid] = key
+		if newest == nil || key.modTime.After(newest.modTime) {
+			newest = key
+		}
+		// Kunci baru menunggu sampai JWKS lama di cache client sudah kedaluwarsa
+		if key.modTime.Before(publishedBefore) && (set.active == nil || key.modTime.After(set.active.modTime)) {
+			set.active = key
+		}
+	}
+
+	if len(set.keys) == 0 {
+		return errors.New("no signing keys found in " + dir)
+	}
+	if set.active == nil {
+		// Only new keys (first setup): no client can have cached a JWKS with older ones
+		set.active = newest
+	}
+	if kid := os.Getenv("JWT_ACTIVE_KID"); kid != "" {
+		active, ok := set.keys[kid]
+		if !ok {
+			return fmt.Errorf("JWT_ACTIVE_KID %q not found in %s", kid, dir)
+		}
+		set.active = active
+	}
+
+	signingKeysMu.Lock()
+	signingKeys = set
+	signingKeysMu.Unlock()
+	return nil
+}
+
+func loadSigningKey(path string) (*signingKey, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{
+		kid:     strings.TrimSuffix(filepath.Base(path), ".pem"),
+		modTime: info.ModTime(),
+	}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.private = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.method, key.private = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return key, nil
+}
+
+func currentSigningKeys() *signingKeySet {
+	signingKeysMu.RLock()
+	defer signingKeysMu.RUnlock()
+	return signingKeys
+}
+
+// NewJWK converts an RSA or Ed25519 public key into a JWK.
+func NewJWK(kid string, pub crypto.PublicKey) (JWK, error) {
+	encode := base64.RawURLEncoding.EncodeToString
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return JWK{Kty: "RSA", Kid: kid, Use: "sig", Alg: "RS256", N: encode(k.N.Bytes()), E: encode(big.NewInt(int64(k.E)).Bytes())}, nil
+	case ed25519.PublicKey:
+		return JWK{Kty: "OKP", Kid: kid, Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: encode(k)}, nil
+	}
+	return JWK{}, fmt.Errorf("unsupported key type %T", pub)
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWKS returns the public part of every key that is valid for verification.
+func PublicJWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	keys := currentSigningKeys()
+	if keys == nil {
+		return set
+	}
+	for kid, key := range keys.keys {
+		if jwk, err := NewJWK(kid, key.private.Public()); err == nil {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
@@ -0,0 +1,107 @@
+package helpers
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeKeyFile(t *testing.T, dir, kid string, key any, modTime time.Time) {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	path := filepath.Join(dir, kid+".pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func tokenKid(t *testing.T, token string) string {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	require.NoError(t, err)
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
+
+func TestSigningKeyRotation(t *testing.T) {
+	t.Cleanup(func() { signingKeys = nil })
+
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writeKeyFile(t, dir, "key-1", rsaKey, time.Now().Add(-time.Hour))
+	require.NoError(t, LoadSigningKeys(dir))
+
+	oldToken, err := CreateToken(uuid.New(), "rotate@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "key-1", tokenKid(t, oldToken))
+
+	// Add a newer Ed25519 key: it is published right away but does not sign while cached JWKS may lack it
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	writeKeyFile(t, dir, "key-2", edKey, time.Now())
+	require.NoError(t, LoadSigningKeys(dir))
+
+	assert.Len(t, PublicJWKS().Keys, 2)
+	pendingToken, err := CreateToken(uuid.New(), "rotate@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "key-1", tokenKid(t, pendingToken))
+
+	// Once older than the JWKS cache max-age it becomes the signing key, the old one still verifies
+	published := time.Now().Add(-JWKSCacheMaxAge - time.Second)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "key-2.pem"), published, published))
+	require.NoError(t, LoadSigningKeys(dir))
+
+	newToken, err := CreateToken(uuid.New(), "rotate@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "key-2", tokenKid(t, newToken))
+
+	_, err = VerifyToken(oldToken)
+	assert.NoError(t, err)
+	_, err = VerifyToken(newToken)
+	assert.NoError(t, err)
+
+	jwks := PublicJWKS()
+	assert.Len(t, jwks.Keys, 2)
+	for _, jwk := range jwks.Keys {
+		_, err := jwk.PublicKey()
+		assert.NoError(t, err)
+	}
+
+	// Retire the old key
+	require.NoError(t, os.Remove(filepath.Join(dir, "key-1.pem")))
+	require.NoError(t, LoadSigningKeys(dir))
+
+	_, err = VerifyToken(oldToken)
+	assert.Error(t, err)
+	_, err = VerifyToken(newToken)
+	assert.NoError(t, err)
+}
+
+func TestVerifyToken_RejectsHMACWhenKeysConfigured(t *testing.T) {
+	t.Cleanup(func() { signingKeys = nil })
+
+	secretKey = "unit-test-secret"
+	hmacToken, err := CreateToken(uuid.New(), "legacy@example.com")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	writeKeyFile(t, dir, "key-1", edKey, time.Now())
+	require.NoError(t, LoadSigningKeys(dir))
+
+	_, err = VerifyToken(hmacToken)
+	assert.Error(t, err)
+}
@@ -0,0 +1,128 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Storage stores uploaded files and returns their public URL
+type Storage interface {
+	// Put stores data under key (e.g. "photos/<user id>/<file>") and returns the public URL
+	Put(ctx context.Context, key string, data []byte, contentType string) (string, error)
+	// Get reads a stored file
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes the file; deleting a missing file is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+var ErrInvalidStorageKey = errors.New("invalid storage key")
+
+var storage Storage
+
+// GetStorage returns the storage configured by InitStorage (tests may replace this function)
+var GetStorage = func() Storage {
+	return storage
+}
+
+// InitStorage configures the upload storage from STORAGE_DRIVER ("local", default, or "s3")
+func InitStorage() error {
+	switch driver := os.Getenv("STORAGE_DRIVER"); driver {
+	case "", "local":
+		storage = NewLocalStorageFromEnv()
+	case "s3":
+		s3, err := NewS3StorageFromEnv()
+		if err != nil {
+			return err
+		}
+		storage = s3
+	default:
+		return fmt.Errorf("unknown STORAGE_DRIVER %q", driver)
+	}
+	return nil
+}
+
+// cleanStorageKey rejects keys that could escape the storage root
+func cleanStorageKey(key string) (string, error) {
+	key = strings.TrimPrefix(filepath.ToSlash(key), "/")
+	if key == "" || strings.Contains(key, "..") || strings.Contains(key, "\\") {
+		return "", ErrInvalidStorageKey
+	}
+	return key, nil
+}
+
+// LocalStorage keeps files on the local filesystem; the router serves Dir under /uploads
+type LocalStorage struct {
+	Dir       string
+	PublicURL string // URL prefix of Dir, e.g. http://localhost:8080/uploads
+}
+
+// LocalStorageURLPath is the path the router serves LocalStorage files under
+const LocalStorageURLPath = "/uploads"
+
+// NewLocalStorageFromEnv uses STORAGE_LOCAL_DIR (default "uploads") and STORAGE_PUBLIC_URL (default "/uploads")
+func NewLocalStorageFromEnv() *LocalStorage {
+	dir := os.Getenv("STORAGE_LOCAL_DIR")
+	if dir == "" {
+		dir = "uploads"
+	}
+	publicURL := os.Getenv("STORAGE_PUBLIC_URL")
+	if publicURL == "" {
+		publicURL = LocalStorageURLPath
+	}
+	return &LocalStorage{Dir: dir, PublicURL: strings.TrimRight(publicURL, "/")}
+}
+
+func (s *LocalStorage) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	key, err := cleanStorageKey(key)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(s.Dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	// Tulis ke file sementara lalu rename supaya file tidak pernah terbaca setengah jadi
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return s.PublicURL + "/" + key, nil
+}
+
+func (s *LocalStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	key, err := cleanStorageKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath.Join(s.Dir, filepath.FromSlash(key)))
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	key, err := cleanStorageKey(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(filepath.Join(s.Dir, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,176 @@
+package helpers
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStorage_PutAndDelete(t *testing.T) {
+	t.Parallel()
+
+	s := &LocalStorage{Dir: t.TempDir(), PublicURL: "http://localhost:8080/uploads"}
+	url, err := s.Put(context.Background(), "photos/u1/p1.png", []byte("png"), "image/png")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/uploads/photos/u1/p1.png", url)
+
+	data, err := os.ReadFile(filepath.Join(s.Dir, "photos", "u1", "p1.png"))
+	assert.NoError(t, err)
+	assert.Equal(t, "png", string(data))
+
+	data, err = s.Get(context.Background(), "photos/u1/p1.png")
+	assert.NoError(t, err)
+	assert.Equal(t, "png", string(data))
+
+	assert.NoError(t, s.Delete(context.Background(), "photos/u1/p1.png"))
+	assert.NoError(t, s.Delete(context.Background(), "photos/u1/p1.png"), "deleting a missing file is not an error")
+
+	_, err = s.Put(context.Background(), "../escape.png", []byte("x"), "image/png")
+	assert.ErrorIs(t, err, ErrInvalidStorageKey)
+}
+
+// Example from the AWS Signature Version 4 documentation
+func TestAWSSigningKey(t *testing.T) {
+	t.Parallel()
+
+	key := awsSigningKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+	assert.Equal(t, "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d", hex.EncodeToString(key))
+}
+
+// fakeS3 is a minimal MinIO-style server: it verifies SigV4 signatures and keeps objects in memory
+type fakeS3 struct {
+	secretKey string
+	region    string
+	mu        sync.Mutex
+	objects   map[string][]byte
+	types     map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if !f.validSignature(r, body) {
+		http.Error(w, "<Error><Code>SignatureDoesNotMatch</Code></Error>", http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		f.objects[r.URL.Path] = body
+		f.types[r.URL.Path] = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// validSignature recomputes the signature from the request as the server received it
+func (f *fakeS3) validSignature(r *http.Request, body []byte) bool {
+	auth := strings.TrimPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ")
+	parts := map[string]string{}
+	for _, p := range strings.Split(auth, ", ") {
+		k, v, _ := strings.Cut(p, "=")
+		parts[k] = v
+	}
+	credential := strings.Split(parts["Credential"], "/") // access key, date, region, service, aws4_request
+	if len(credential) != 5 || credential[2] != f.region || r.Header.Get("X-Amz-Content-Sha256") != sha256Hex(body) {
+		return false
+	}
+
+	names := strings.Split(parts["SignedHeaders"], ";")
+	sort.Strings(names)
+	var headers strings.Builder
+	for _, name := range names {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		headers.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	canonical := strings.Join([]string{r.Method, r.URL.EscapedPath(), r.URL.RawQuery, headers.String(), strings.Join(names, ";"), sha256Hex(body)}, "\n")
+	scope := strings.Join(credential[1:], "/")
+	stringToSign := "AWS4-HMAC-SHA256\n" + r.Header.Get("X-Amz-Date") + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+	expected := hex.EncodeToString(hmacSHA256(awsSigningKey(f.secretKey, credential[1], f.region, "s3"), stringToSign))
+	return expected == parts["Signature"]
+}
+
+func TestS3Storage_AgainstFakeServer(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeS3{secretKey: "minio-secret", region: "us-east-1", objects: map[string][]byte{}, types: map[string]string{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s := &S3Storage{
+		Endpoint:  server.URL,
+		Region:    "us-east-1",
+		Bucket:    "mygram",
+		AccessKey: "minio",
+		SecretKey: "minio-secret",
+		PathStyle: true,
+		Client:    server.Client(),
+	}
+
+	url, err := s.Put(context.Background(), "photos/u1/p1.webp", []byte("webp-bytes"), "image/webp")
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/mygram/photos/u1/p1.webp", url)
+	assert.Equal(t, "webp-bytes", string(fake.objects["/mygram/photos/u1/p1.webp"]))
+	assert.Equal(t, "image/webp", fake.types["/mygram/photos/u1/p1.webp"])
+
+	data, err := s.Get(context.Background(), "photos/u1/p1.webp")
+	assert.NoError(t, err)
+	assert.Equal(t, "webp-bytes", string(data))
+
+	s.PublicURL = "https://cdn.example.com"
+	url, err = s.Put(context.Background(), "photos/u1/p2.png", []byte("png"), "image/png")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/photos/u1/p2.png", url)
+
+	assert.NoError(t, s.Delete(context.Background(), "photos/u1/p1.webp"))
+	assert.NotContains(t, fake.objects, "/mygram/photos/u1/p1.webp")
+
+	// Wrong credentials are rejected by the server
+	s.SecretKey = "wrong"
+	_, err = s.Put(context.Background(), "photos/u1/p3.png", []byte("png"), "image/png")
+	assert.ErrorContains(t, err, "403")
+}
+
+func TestSniffImageType(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"\xff\xd8\xff\xe0\x00\x10JFIF\x00":     "image/jpeg",
+		"\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR":  "image/png",
+		"RIFF\x24\x00\x00\x00WEBPVP8 \x18\x00": "image/webp",
+	}
+	for data, want := range cases {
+		got, _, err := SniffImageType([]byte(data))
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, _, err := SniffImageType([]byte("GIF89a\x01\x00\x01\x00"))
+	assert.ErrorIs(t, err, ErrUnsupportedImageType)
+	_, _, err = SniffImageType([]byte("<html><body>not an image</body></html>"))
+	assert.ErrorIs(t, err, ErrUnsupportedImageType)
+}
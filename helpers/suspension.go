@@ -0,0 +1,103 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"mygram-api/database"
+	"mygram-api/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	// suspendedUserKeyPrefix + user ID: suspension message of a suspended user, expires with the suspension.
+	// An empty value means the user was found not suspended.
+	suspendedUserKeyPrefix = "auth:suspended:"
+	// notSuspendedCacheTTL is how long a "not suspended" result read from the database is cached
+	notSuspendedCacheTTL = 5 * time.Minute
+)
+
+// SuspensionMessage is the error shown to a suspended user
+func SuspensionMessage(user models.User) string {
+	message := "Your account has been suspended"
+	if user.SuspendedUntil != nil {
+		message += " until " + user.SuspendedUntil.UTC().Format(time.RFC3339)
+	}
+	if user.SuspensionReason != "" {
+		message += ". Reason: " + user.SuspensionReason
+	}
+	return message
+}
+
+// MarkUserSuspended caches the suspension in Redis so Authentication can reject the user
+// without a database query.
+func MarkUserSuspended(user models.User) error {
+	rdb := database.GetRedis()
+	if rdb == nil {
+		return nil
+	}
+
+	var ttl time.Duration // 0 = no expiry (suspended indefinitely)
+	if user.SuspendedUntil != nil {
+		ttl = time.Until(*user.SuspendedUntil)
+		if ttl <= 0 {
+			return nil
+		}
+	}
+	return rdb.Set(context.Background(), suspendedUserKeyPrefix+user.ID.String(), SuspensionMessage(user), ttl).Err()
+}
+
+// ClearUserSuspended removes the cached suspension of the user
+func ClearUserSuspended(userID uuid.UUID) error {
+	rdb := database.GetRedis()
+	if rdb == nil {
+		return nil
+	}
+	return rdb.Del(context.Background(), suspendedUserKeyPrefix+userID.String()).Err()
+}
+
+// CheckUserSuspended returns the suspension message when the user is currently suspended.
+// It reads Redis when available; on a cache miss (or without Redis) it reads the user's row
+// and caches the result.
+func CheckUserSuspended(db *gorm.DB, userID uuid.UUID) (string, bool, error) {
+	rdb := database.GetRedis()
+	key := suspendedUserKeyPrefix + userID.String()
+	if rdb != nil {
+		message, err := rdb.Get(context.Background(), key).Result()
+		if err == nil {
+			return message, message != "", nil
+		}
+		if !errors.Is(err, redis.Nil) {
+			return "", false, err
+		}
+		// Cache kosong (mis. Redis baru di-restart): baca dari database lalu isi ulang
+	}
+
+	var user models.User
+	if err := db.Select("id", "suspended_at", "suspended_until", "suspension_reason").First(&user, "id = ?", userID).Error; err != nil {
+		return "", false, err
+	}
+	suspended := user.IsSuspended(time.Now())
+
+	if rdb != nil {
+		var err error
+		if suspended {
+			err = MarkUserSuspended(user)
+		} else {
+			// SetNX: a suspension cached concurrently by MarkUserSuspended must not be overwritten
+			err = rdb.SetNX(context.Background(), key, "", notSuspendedCacheTTL).Err()
+		}
+		if err != nil {
+			log.Printf("Failed to cache suspension state of user %s: %v", userID, err)
+		}
+	}
+
+	if !suspended {
+		return "", false, nil
+	}
+	return SuspensionMessage(user), true, nil
+}
@@ -0,0 +1,145 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"mygram-api/database"
+	"mygram-api/models"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	// revokedTokenKeyPrefix + jti: access tokens that were logged out
+	revokedTokenKeyPrefix = "auth:revoked:"
+	// tokensValidAfterKeyPrefix + user ID: tokens issued before this unix time (milliseconds) are rejected
+	tokensValidAfterKeyPrefix = "auth:valid_after:"
+)
+
+// RevokeToken puts a single access token (by jti) on the denylist until it expires.
+func RevokeToken(jti string, expiresAt time.Time) error {
+	rdb := database.GetRedis()
+	if rdb == nil {
+		log.Println("WARNING: Redis client is NIL. Access token denylist is disabled.")
+		return nil
+	}
+
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		// Token already expired, nothing to deny
+		return nil
+	}
+	return rdb.Set(context.Background(), revokedTokenKeyPrefix+jti, 1, ttl).Err()
+}
+
+// RevokeTokensIssuedBefore rejects every access token of the user issued before the given time.
+func RevokeTokensIssuedBefore(userID uuid.UUID, before time.Time) error {
+	rdb := database.GetRedis()
+	if rdb == nil {
+		log.Println("WARNING: Redis client is NIL. Access token denylist is disabled.")
+		return nil
+	}
+
+	// Older tokens expire by themselves after AccessTokenTTL, so the marker can expire too
+	return rdb.Set(context.Background(), tokensValidAfterKeyPrefix+userID.String(), before.UnixMilli(), AccessTokenTTL()).Err()
+}
+
+// RevokeUserSessions logs the user out everywhere: all refresh tokens are revoked
+// and all access tokens issued until now are denied.
+func RevokeUserSessions(db *gorm.DB, userID uuid.UUID) error {
+	var sessionIDs []uuid.UUID
+	if err := db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Pluck("id", &sessionIDs).Error; err != nil {
+		return err
+	}
+	if err := revokeSessions(db, sessionIDs); err != nil {
+		return err
+	}
+	if err := db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return err
+	}
+	return RevokeTokensIssuedBefore(userID, time.Now())
+}
+
+// RevokeOtherUserSessions logs the user out of every session except keepSessionID.
+// All access tokens issued until now are denied, including the caller's, so the caller
+// must issue a fresh access token for the kept session.
+func RevokeOtherUserSessions(db *gorm.DB, userID, keepSessionID uuid.UUID) error {
+	var sessionIDs []uuid.UUID
+	if err := db.Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepSessionID).
+		Pluck("id", &sessionIDs).Error; err != nil {
+		return err
+	}
+	if err := revokeSessions(db, sessionIDs); err != nil {
+		return err
+	}
+	if err := db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, keepSessionID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return err
+	}
+	return RevokeTokensIssuedBefore(userID, time.Now())
+}
+
+// revokeSessions marks the sessions revoked in the database and in Redis, so CheckSession
+// rejects their access tokens right away.
+func revokeSessions(db *gorm.DB, sessionIDs []uuid.UUID) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+	if err := db.Model(&models.Session{}).
+		Where("id IN ? AND revoked_at IS NULL", sessionIDs).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return err
+	}
+	return markSessionsRevoked(sessionIDs...)
+}
+
+// IsTokenRevoked checks the claims of a verified access token against the denylist.
+func IsTokenRevoked(claims map[string]any) (bool, error) {
+	rdb := database.GetRedis()
+	if rdb == nil {
+		return false, nil
+	}
+	ctx := context.Background()
+
+	// 1. Single token logout
+	if jti, ok := claims["jti"].(string); ok && jti != "" {
+		n, err := rdb.Exists(ctx, revokedTokenKeyPrefix+jti).Result()
+		if err != nil {
+			return false, err
+		}
+		if n > 0 {
+			return true, nil
+		}
+	}
+
+	// 2. Logout everywhere
+	userID, _ := claims["id"].(string)
+	validAfter, err := rdb.Get(ctx, tokensValidAfterKeyPrefix+userID).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	cutoff, _ := strconv.ParseInt(validAfter, 10, 64)
+	iat, _ := claims["iat"].(float64)
+	return issuedBefore(iat, cutoff), nil
+}
+
+// issuedBefore reports whether a token with the given iat (seconds, possibly fractional)
+// was issued before the cutoff (unix milliseconds).
+func issuedBefore(iat float64, cutoffMilli int64) bool {
+	return int64(math.Round(iat*1000)) < cutoffMilli
+}
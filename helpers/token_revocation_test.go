@@ -0,0 +1,19 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssuedBefore_SameSecondAsCutoff(t *testing.T) {
+	t.Parallel()
+
+	// Revocation 600ms into a second, tokens issued within that same second
+	cutoff := time.Unix(1_700_000_000, 600*int64(time.Millisecond))
+
+	assert.True(t, issuedBefore(1_700_000_000.250, cutoff.UnixMilli()), "token issued before the cutoff must be revoked")
+	assert.False(t, issuedBefore(1_700_000_000.900, cutoff.UnixMilli()), "token issued after the cutoff must stay valid")
+	assert.False(t, issuedBefore(1_700_000_000.600, cutoff.UnixMilli()), "token issued at the cutoff must stay valid")
+}
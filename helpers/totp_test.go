@@ -0,0 +1,55 @@
+package helpers
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	t.Parallel()
+
+	// RFC 6238 appendix B, SHA1 seed "12345678901234567890" (last 6 digits of the 8 digit codes)
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for unix, want := range vectors {
+		code, err := TOTPCode(secret, time.Unix(unix, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, want, code, "code at %d", unix)
+	}
+}
+
+func TestValidateTOTP_AcceptsClockDriftOfOneStep(t *testing.T) {
+	t.Parallel()
+
+	secret, err := GenerateTOTPSecret()
+	assert.NoError(t, err)
+
+	now := time.Now()
+	previous, _ := TOTPCode(secret, now.Add(-30*time.Second))
+	_, ok := ValidateTOTP(secret, previous, now)
+	assert.True(t, ok, "code from the previous step should be accepted")
+
+	old, _ := TOTPCode(secret, now.Add(-5*time.Minute))
+	_, ok = ValidateTOTP(secret, old, now)
+	assert.False(t, ok, "old code should be rejected")
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	t.Parallel()
+
+	uri := TOTPProvisioningURI("MyGram", "john@example.com", "JBSWY3DPEHPK3PXP")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/MyGram:john@example.com?"))
+	assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	assert.Contains(t, uri, "issuer=MyGram")
+}
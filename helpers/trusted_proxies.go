@@ -0,0 +1,20 @@
+package helpers
+
+import (
+	"os"
+	"strings"
+)
+
+// TrustedProxies returns the reverse proxies (IPs or CIDRs) whose X-Forwarded-For / X-Real-IP
+// headers are used for the client IP, configured as a comma separated TRUSTED_PROXIES.
+// Default is none: the client IP is the address of the TCP connection, so login throttling,
+// rate limiting and sessions cannot be fooled with a spoofed header.
+func TrustedProxies() []string {
+	var proxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
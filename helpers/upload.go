@@ -0,0 +1,67 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+var (
+	ErrUnsupportedImageType = errors.New("unsupported image type, only JPEG, PNG and WebP are allowed")
+	ErrImageTooLarge        = errors.New("image is too large")
+)
+
+// allowedImageTypes maps the sniffed content type to the stored file extension
+var allowedImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// MaxPhotoUploadSize is the maximum size of an uploaded photo in bytes (PHOTO_MAX_UPLOAD_SIZE, default 10 MiB)
+func MaxPhotoUploadSize() int64 {
+	return envInt64("PHOTO_MAX_UPLOAD_SIZE", 10<<20)
+}
+
+// ReadPhotoUpload reads an uploaded image, enforcing MaxPhotoUploadSize. The content type is sniffed
+// from the bytes; the client-supplied Content-Type and file name are ignored.
+func ReadPhotoUpload(fh *multipart.FileHeader) (data []byte, contentType, ext string, err error) {
+	max := MaxPhotoUploadSize()
+	if fh.Size > max {
+		return nil, "", "", tooLarge(max)
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return nil, "", "", err
+	}
+	defer f.Close()
+
+	data, err = io.ReadAll(io.LimitReader(f, max+1))
+	if err != nil {
+		return nil, "", "", err
+	}
+	if int64(len(data)) > max {
+		return nil, "", "", tooLarge(max)
+	}
+	contentType, ext, err = SniffImageType(data)
+	return data, contentType, ext, err
+}
+
+// SniffImageType detects JPEG, PNG and WebP from the magic bytes
+func SniffImageType(data []byte) (contentType, ext string, err error) {
+	contentType = http.DetectContentType(data)
+	ext, ok := allowedImageTypes[contentType]
+	if !ok {
+		return "", "", ErrUnsupportedImageType
+	}
+	return contentType, ext, nil
+}
+
+func tooLarge(max int64) error {
+	if max%(1<<20) == 0 {
+		return fmt.Errorf("%w (max %d MB)", ErrImageTooLarge, max>>20)
+	}
+	return fmt.Errorf("%w (max %d bytes)", ErrImageTooLarge, max)
+}
@@ -0,0 +1,88 @@
+package helpers
+
+import (
+	"errors"
+	"mygram-api/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrUserTokenInvalid = errors.New("invalid or expired token")
+
+// CreateUserToken stores a new single-use token for the given purpose and returns the raw token.
+// Outstanding tokens of the same purpose are invalidated, so only the latest email link works.
+func CreateUserToken(db *gorm.DB, userID uuid.UUID, purpose string, ttl time.Duration) (string, error) {
+	return createUserToken(db, models.UserToken{UserID: userID, Purpose: purpose}, ttl)
+}
+
+// CreateEmailVerificationToken stores a single-use token that confirms the given email address for the user.
+func CreateEmailVerificationToken(db *gorm.DB, userID uuid.UUID, email string, ttl time.Duration) (string, error) {
+	return createUserToken(db, models.UserToken{
+		UserID:  userID,
+		Purpose: models.UserTokenEmailVerification,
+		Email:   email,
+	}, ttl)
+}
+
+// CreateEmailChangeToken stores a single-use token that confirms a new email address for the user.
+// It has its own purpose, so resending the verification of the current address keeps it valid.
+func CreateEmailChangeToken(db *gorm.DB, userID uuid.UUID, email string, ttl time.Duration) (string, error) {
+	return createUserToken(db, models.UserToken{
+		UserID:  userID,
+		Purpose: models.UserTokenEmailChange,
+		Email:   email,
+	}, ttl)
+}
+
+func createUserToken(db *gorm.DB, ut models.UserToken, ttl time.Duration) (string, error) {
+	raw, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", ut.UserID, ut.Purpose).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		ut.TokenHash = HashOpaqueToken(raw)
+		ut.ExpiresAt = time.Now().Add(ttl)
+		return tx.Create(&ut).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// ConsumeUserToken marks a token as used and returns it.
+// It returns ErrUserTokenInvalid if the token is unknown, expired, already used or has none of the given purposes.
+func ConsumeUserToken(db *gorm.DB, raw string, purposes ...string) (*models.UserToken, error) {
+	var ut models.UserToken
+	if err := db.Where("token_hash = ? AND purpose IN ?", HashOpaqueToken(raw), purposes).First(&ut).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserTokenInvalid
+		}
+		return nil, err
+	}
+
+	if ut.UsedAt != nil || time.Now().After(ut.ExpiresAt) {
+		return nil, ErrUserTokenInvalid
+	}
+
+	// Conditional update so the same token cannot be consumed twice concurrently
+	res := db.Model(&models.UserToken{}).
+		Where("id = ? AND used_at IS NULL", ut.ID).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrUserTokenInvalid
+	}
+	return &ut, nil
+}
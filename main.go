@@ -8,6 +8,7 @@ import (
 	"mygram-api/router"
 	"os"
 	"strings"
+	"time"
 )
 
 var isReleaseBuild = "no"
@@ -30,6 +31,29 @@ func main() {
 	database.StartDB()
 	helpers.RegisterCustomValidator()
 
+	// Promote ADMIN_EMAIL to admin while there is no admin yet (first deployment).
+	if promoted, err := helpers.BootstrapAdmin(database.GetDB()); err != nil {
+		log.Printf("Warning: failed to bootstrap the first admin: %v", err)
+	} else if promoted {
+		log.Printf("Promoted %s to admin.", os.Getenv("ADMIN_EMAIL"))
+	}
+
+	// Load asymmetric JWT signing keys (if JWT_KEY_DIR is set) and pick up rotated keys every minute.
+	if err := helpers.InitSigningKeys(); err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+	helpers.StartSigningKeyReloader(time.Minute)
+
+	// Storage for uploaded photos (local filesystem or S3-compatible bucket).
+	if err := helpers.InitStorage(); err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	// Background image pipeline (variants, EXIF stripping) for uploaded photos.
+	helpers.StartPhotoProcessor(database.GetDB(), 2)
+
+	// Recompute the explore ranking in the background (requires Redis).
+	helpers.StartExploreWorker(database.GetDB(), helpers.ExploreRefreshInterval())
+
 	// Initialize email templates at startup so template errors are detected early.
 	if err := helpers.InitEmailTemplates(); err != nil {
 		log.Printf("Warning: failed to initialize email templates: %v. Templated emails will fallback to plain-text.", err)
@@ -1,16 +1,22 @@
 package middlewares
 
 import (
+	"errors"
 	"maps"
 	"mygram-api/database"
 	"mygram-api/dto"
 	"mygram-api/helpers"
 	"mygram-api/models"
 	"net/http"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // Authentication is a middleware to verify the JWT token
@@ -36,6 +42,36 @@ func Authentication() gin.HandlerFunc {
 
 		tokenString := strings.Split(authHeader, " ")[1]
 
+		// Personal access tokens (scripts & integrations) populate the same userData as JWTs
+		if strings.HasPrefix(tokenString, helpers.PersonalAccessTokenPrefix) {
+			pat, err := helpers.AuthenticatePersonalAccessToken(database.GetDB(), tokenString)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, dto.BaseResponseError{
+					Success: false,
+					Message: err.Error(),
+				})
+				return
+			}
+
+			if pat.User.IsSuspended(time.Now()) {
+				c.AbortWithStatusJSON(http.StatusForbidden, dto.BaseResponseError{
+					Success: false,
+					Message: helpers.SuspensionMessage(*pat.User),
+				})
+				return
+			}
+
+			c.Set("userData", map[string]any{
+				"id":     pat.UserID.String(),
+				"email":  pat.User.Email,
+				"typ":    helpers.TokenTypePersonalAccess,
+				"role":   pat.User.Role,
+				"scopes": helpers.SplitScopes(pat.Scopes),
+			})
+			c.Next()
+			return
+		}
+
 		// Call helper to verify and parse token
 		claims, err := helpers.VerifyToken(tokenString)
 		if err != nil {
@@ -46,6 +82,82 @@ func Authentication() gin.HandlerFunc {
 			return
 		}
 
+		// Only access tokens can be used to call the API (e.g. not an mfa_pending token)
+		if typ, _ := claims["typ"].(string); typ != helpers.TokenTypeAccess {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.BaseResponseError{
+				Success: false,
+				Message: "Invalid token type",
+			})
+			return
+		}
+
+		// Reject suspended (or deleted) accounts
+		userIDStr, _ := claims["id"].(string)
+		userID, _ := uuid.Parse(userIDStr)
+		if message, suspended, err := helpers.CheckUserSuspended(database.GetDB(), userID); err != nil || suspended {
+			switch {
+			case suspended:
+				c.AbortWithStatusJSON(http.StatusForbidden, dto.BaseResponseError{
+					Success: false,
+					Message: message,
+				})
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				c.AbortWithStatusJSON(http.StatusUnauthorized, dto.BaseResponseError{
+					Success: false,
+					Message: "Account not found",
+				})
+			default:
+				c.AbortWithStatusJSON(http.StatusInternalServerError, dto.BaseResponseError{
+					Success: false,
+					Message: "Failed to validate token",
+				})
+			}
+			return
+		}
+
+		// Reject tokens that were logged out (Redis denylist)
+		revoked, err := helpers.IsTokenRevoked(claims)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, dto.BaseResponseError{
+				Success: false,
+				Message: "Failed to validate token",
+			})
+			return
+		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.BaseResponseError{
+				Success: false,
+				Message: "Token has been revoked",
+			})
+			return
+		}
+
+		// Reject tokens of a revoked session (device logged out) and update its last-seen time
+		if sid, ok := claims["sid"].(string); ok {
+			sessionID, err := uuid.Parse(sid)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, dto.BaseResponseError{
+					Success: false,
+					Message: "Invalid session",
+				})
+				return
+			}
+			if err := helpers.CheckSession(database.GetDB(), sessionID); err != nil {
+				if errors.Is(err, helpers.ErrSessionRevoked) {
+					c.AbortWithStatusJSON(http.StatusUnauthorized, dto.BaseResponseError{
+						Success: false,
+						Message: "Session has been revoked",
+					})
+					return
+				}
+				c.AbortWithStatusJSON(http.StatusInternalServerError, dto.BaseResponseError{
+					Success: false,
+					Message: "Failed to validate token",
+				})
+				return
+			}
+		}
+
 		// Convert claims (jwt.MapClaims) into a plain map[string]any to avoid named-type
 		userData := map[string]any{}
 		maps.Copy(userData, claims)
@@ -56,7 +168,64 @@ func Authentication() gin.HandlerFunc {
 	}
 }
 
-// Authorization checks if the authenticated user owns the resource
+// RequireScope restricts a route to personal access tokens granted the scope.
+// Requests authenticated with a login session (JWT) are not restricted.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userData := c.MustGet("userData").(map[string]any)
+		if typ, _ := userData["typ"].(string); typ != helpers.TokenTypePersonalAccess {
+			c.Next()
+			return
+		}
+
+		scopes, _ := userData["scopes"].([]string)
+		if !slices.Contains(scopes, scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, dto.BaseResponseError{
+				Success: false,
+				Message: "Token is missing the required scope: " + scope,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// SessionOnly rejects personal access tokens, for account management routes
+// that must only be reachable from a real login session.
+func SessionOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userData := c.MustGet("userData").(map[string]any)
+		if typ, _ := userData["typ"].(string); typ == helpers.TokenTypePersonalAccess {
+			c.AbortWithStatusJSON(http.StatusForbidden, dto.BaseResponseError{
+				Success: false,
+				Message: "This endpoint cannot be used with a personal access token",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireRole restricts a route to users with one of the given roles (from the token).
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userData := c.MustGet("userData").(map[string]any)
+		if role, _ := userData["role"].(string); !slices.Contains(roles, role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, dto.BaseResponseError{
+				Success: false,
+				Message: "You do not have permission to access this resource",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Authorization checks if the authenticated user owns the resource.
+// Moderators and admins may act on any resource; every such bypass is written to the audit log.
 func Authorization(resourceType string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		db := database.GetDB()
@@ -117,9 +286,59 @@ func Authorization(resourceType string) gin.HandlerFunc {
 
 		// Authorization check
 		if ownedID != userID {
+			role, _ := userData["role"].(string)
+			if !helpers.CanModerate(role) {
+				c.AbortWithStatusJSON(http.StatusForbidden, dto.BaseResponseError{
+					Success: false,
+					Message: "You are not authorized to modify this " + resourceType,
+				})
+				return
+			}
+
+			// Moderation: the action is only allowed once it is recorded
+			if err := helpers.RecordAudit(c, db, models.AuditLog{
+				Action:       "moderation.bypass",
+				ResourceType: resourceType,
+				ResourceID:   resourceID.String(),
+				TargetUserID: &ownedID,
+			}); err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, dto.BaseResponseError{
+					Success: false,
+					Message: "Failed to write audit log",
+				})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// RequireVerifiedEmail blocks the request until the user has confirmed their email address.
+// The policy is enabled with REQUIRE_VERIFIED_EMAIL=true, otherwise the middleware does nothing.
+func RequireVerifiedEmail() gin.HandlerFunc {
+	if enabled, _ := strconv.ParseBool(os.Getenv("REQUIRE_VERIFIED_EMAIL")); !enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		db := database.GetDB()
+		userData := c.MustGet("userData").(map[string]any)
+		userID := uuid.MustParse(userData["id"].(string))
+
+		var user models.User
+		if err := db.Select("email_verified_at").First(&user, "id = ?", userID).Error; err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.BaseResponseError{
+				Success: false,
+				Message: "Account not found",
+			})
+			return
+		}
+
+		if user.EmailVerifiedAt == nil {
 			c.AbortWithStatusJSON(http.StatusForbidden, dto.BaseResponseError{
 				Success: false,
-				Message: "You are not authorized to modify this " + resourceType,
+				Message: "Please verify your email address first",
 			})
 			return
 		}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditLog records privileged actions, e.g. a moderator editing another user's photo
+// or an admin changing a role.
+type AuditLog struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	ActorID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"actor_id"` // User who performed the action
+	ActorRole    string     `gorm:"type:varchar(20)" json:"actor_role"`
+	Action       string     `gorm:"not null;index" json:"action"` // e.g. "moderation.bypass"
+	ResourceType string     `json:"resource_type"`
+	ResourceID   string     `json:"resource_id"`
+	TargetUserID *uuid.UUID `gorm:"type:uuid;index" json:"target_user_id,omitempty"` // Owner of the resource / affected user
+	Method       string     `json:"method"`
+	Path         string     `json:"path"`
+	Details      string     `gorm:"type:text" json:"details,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// BeforeCreate sets a UUID in application code if it's not already set.
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
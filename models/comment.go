@@ -4,12 +4,13 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Comment struct {
-	ID              uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
-	UserID          uuid.UUID  `json:"user_id"`  // Foreign Key of User
-	PhotoID         uuid.UUID  `json:"photo_id"` // Foreign Key of Photo
+	ID              uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID          uuid.UUID  `json:"user_id"`               // Foreign Key of User
+	PhotoID         uuid.UUID  `gorm:"index" json:"photo_id"` // Foreign Key of Photo
 	Message         string     `gorm:"not null" json:"message"`
 	ParentCommentID *uuid.UUID `gorm:"type:uuid;default:null" json:"parent_comment_id,omitempty"`  // FK ke Comment.ID
 	ParentComment   *Comment   `gorm:"foreignkey:ParentCommentID" json:"parent_comment,omitempty"` // Relasi ke komentar induk
@@ -18,4 +19,13 @@ type Comment struct {
 	Photo           *Photo     `json:"Photo,omitempty"`
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
+	ReplyCount      int64      `gorm:"->;-:migration" json:"-"` // Hanya terisi jika query memilih "... AS reply_count"
+}
+
+// BeforeCreate sets a UUID in application code if it's not already set (works on SQLite too).
+func (cm *Comment) BeforeCreate(tx *gorm.DB) (err error) {
+	if cm.ID == uuid.Nil {
+		cm.ID = uuid.New()
+	}
+	return nil
 }
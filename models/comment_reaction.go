@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CommentReaction is a reaction of UserID on a comment or reply. A user reacts at most once per type.
+type CommentReaction struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CommentID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_comment_reaction,priority:1" json:"comment_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_comment_reaction,priority:2" json:"user_id"`
+	Type      string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_comment_reaction,priority:3" json:"type"`
+	Comment   *Comment  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"Comment,omitempty"`
+	User      *User     `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"User,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate sets a UUID in application code if it's not already set.
+func (r *CommentReaction) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Follow statuses. Following a private profile creates a pending follow request.
+const (
+	FollowStatusAccepted = "accepted"
+	FollowStatusPending  = "pending"
+)
+
+// Follow is an edge of the social graph: FollowerID follows FollowingID.
+type Follow struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	FollowerID  uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_follow_pair;index:idx_follow_follower_status,priority:1" json:"follower_id"`
+	FollowingID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_follow_pair;index:idx_follow_following_status,priority:1" json:"following_id"`
+	Status      string     `gorm:"type:varchar(10);not null;default:accepted;index:idx_follow_follower_status,priority:2;index:idx_follow_following_status,priority:2" json:"status"`
+	AcceptedAt  *time.Time `json:"accepted_at,omitempty"`
+	Follower    *User      `gorm:"foreignKey:FollowerID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"Follower,omitempty"`
+	Following   *User      `gorm:"foreignKey:FollowingID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"Following,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// BeforeCreate sets a UUID in application code if it's not already set.
+func (f *Follow) BeforeCreate(tx *gorm.DB) (err error) {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
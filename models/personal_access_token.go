@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PersonalAccessToken is a named, scoped API key for scripts and integrations.
+// Only the SHA-256 hash of the token is stored; Prefix is kept to help users recognise it.
+type PersonalAccessToken struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"` // Foreign Key of User
+	Name       string     `gorm:"not null" json:"name"`
+	TokenHash  string     `gorm:"not null;uniqueIndex" json:"-"`
+	Prefix     string     `gorm:"not null" json:"prefix"`
+	Scopes     string     `gorm:"not null" json:"scopes"` // Comma separated, e.g. "photos:read,photos:write"
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`   // NULL means the token never expires
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	User       *User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"User,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// BeforeCreate sets a UUID in application code if it's not already set.
+func (pat *PersonalAccessToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if pat.ID == uuid.Nil {
+		pat.ID = uuid.New()
+	}
+	return nil
+}
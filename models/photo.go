@@ -1,19 +1,66 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Processing states of a photo. Uploaded photos stay "processing" until their variants exist.
+const (
+	PhotoStatusProcessing = "processing"
+	PhotoStatusReady      = "ready"
+	PhotoStatusFailed     = "failed"
 )
 
 type Photo struct {
-	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
-	Title     string    `gorm:"not null" json:"title"`
-	Caption   string    `json:"caption"`
-	PhotoUrl  string    `gorm:"not null" json:"photo_url"`
-	UserID    uuid.UUID `json:"user_id"` // Foreign Key of User
-	User      *User     `json:"User,omitempty"`
-	Comments  []Comment `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"comments"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Title        string    `gorm:"not null" json:"title"`
+	Caption      string    `json:"caption"`
+	PhotoUrl     string    `gorm:"not null" json:"photo_url"` // Full variant for uploaded photos
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
+	MediumURL    string    `json:"medium_url,omitempty"`
+	Width        int       `json:"width,omitempty"`
+	Height       int       `json:"height,omitempty"`
+	Status       string    `gorm:"type:varchar(20);not null;default:ready;index" json:"status"`
+	StorageKey   string    `gorm:"type:varchar(255)" json:"-"`                             // Key di Storage untuk file upload asli (sampai diproses)
+	VariantKeys  string    `gorm:"type:text" json:"-"`                                     // Key variant di Storage, dipisah koma
+	UserID       uuid.UUID `gorm:"index:idx_photo_user_created,priority:1" json:"user_id"` // Foreign Key of User
+	User         *User     `json:"User,omitempty"`
+	Comments     []Comment `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"comments"`
+	LikeCount    int64     `gorm:"not null;default:0" json:"like_count"`
+	ViewCount    int64     `gorm:"not null;default:0" json:"view_count"`
+	CreatedAt    time.Time `gorm:"index:idx_photo_user_created,priority:2" json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// BeforeCreate sets a UUID in application code if it's not already set (works on SQLite too).
+func (p *Photo) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	if p.Status == "" {
+		p.Status = PhotoStatusReady
+	}
+	// Keep the timestamp at database precision so feed cursors built from it match stored rows
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = time.Now().Truncate(time.Microsecond)
+	}
+	return nil
+}
+
+// StoredKeys returns every Storage key owned by the photo (original upload and variants)
+func (p *Photo) StoredKeys() []string {
+	var keys []string
+	if p.StorageKey != "" {
+		keys = append(keys, p.StorageKey)
+	}
+	for _, k := range strings.Split(p.VariantKeys, ",") {
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
 }
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PhotoLike is a like of UserID on PhotoID. Photo.LikeCount is kept in sync in the same transaction.
+type PhotoLike struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_photo_like_pair,priority:2" json:"user_id"`
+	PhotoID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_photo_like_pair,priority:1" json:"photo_id"`
+	User      *User     `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"User,omitempty"`
+	Photo     *Photo    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"Photo,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate sets a UUID in application code if it's not already set.
+func (l *PhotoLike) BeforeCreate(tx *gorm.DB) (err error) {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
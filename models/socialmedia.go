@@ -4,10 +4,11 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type SocialMedia struct {
-	ID             uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
 	Name           string    `gorm:"not null" json:"name"`
 	SocialMediaUrl string    `gorm:"not null" json:"social_media_url"`
 	UserID         uuid.UUID `json:"user_id"` // Foreign Key of User
@@ -15,3 +16,11 @@ type SocialMedia struct {
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
+
+// BeforeCreate sets a UUID in application code if it's not already set (works on SQLite too).
+func (sm *SocialMedia) BeforeCreate(tx *gorm.DB) (err error) {
+	if sm.ID == uuid.Nil {
+		sm.ID = uuid.New()
+	}
+	return nil
+}
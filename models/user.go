@@ -7,17 +7,37 @@ import (
 	"gorm.io/gorm"
 )
 
+// Roles a user can have. Moderators and admins can moderate other users' content.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 type User struct {
-	ID           uuid.UUID     `gorm:"type:uuid;primaryKey" json:"id"` // Modification: Use UUID
-	Username     string        `gorm:"not null;unique" json:"username"`
-	Email        string        `gorm:"not null;unique" json:"email"`
-	Password     string        `gorm:"not null" json:"password"`
-	Age          int           `gorm:"not null" json:"age"`
-	Photos       []Photo       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"photos"`
-	Comments     []Comment     `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"comments"`
-	SocialMedias []SocialMedia `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"social_medias"`
-	CreatedAt    time.Time     `json:"created_at"`
-	UpdatedAt    time.Time     `json:"updated_at"`
+	ID               uuid.UUID     `gorm:"type:uuid;primaryKey" json:"id"` // Modification: Use UUID
+	Username         string        `gorm:"not null;unique" json:"username"`
+	Email            string        `gorm:"not null;unique" json:"email"`
+	EmailVerifiedAt  *time.Time    `json:"email_verified_at"` // NULL until the email address is confirmed
+	Password         string        `gorm:"not null" json:"password"`
+	Age              int           `gorm:"not null" json:"age"`
+	DisplayName      string        `gorm:"type:varchar(50)" json:"display_name"`
+	Bio              string        `gorm:"type:varchar(300)" json:"bio"`
+	AvatarURL        string        `json:"avatar_url"`
+	Website          string        `json:"website"`
+	IsPrivate        bool          `gorm:"not null;default:false" json:"is_private"` // Private profiles hide their photos from non-followers
+	Role             string        `gorm:"type:varchar(20);not null;default:user" json:"role"`
+	SuspendedAt      *time.Time    `json:"suspended_at,omitempty"`
+	SuspendedUntil   *time.Time    `json:"suspended_until,omitempty"` // NULL while suspended means indefinitely
+	SuspensionReason string        `json:"suspension_reason,omitempty"`
+	TOTPSecret       string        `json:"-"`               // Base32 TOTP secret, set during 2FA enrollment
+	TOTPEnabledAt    *time.Time    `json:"totp_enabled_at"` // NULL while 2FA is off or not confirmed yet
+	TOTPLastStep     int64         `json:"-"`               // Last accepted TOTP time step (replay protection)
+	Photos           []Photo       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"photos"`
+	Comments         []Comment     `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"comments"`
+	SocialMedias     []SocialMedia `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"social_medias"`
+	CreatedAt        time.Time     `json:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at"`
 }
 
 // BeforeCreate hook will set a UUID in application code if it's not already set.
@@ -26,5 +46,16 @@ func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 	if u.ID == uuid.Nil {
 		u.ID = uuid.New()
 	}
+	if u.Role == "" {
+		u.Role = RoleUser
+	}
 	return nil
 }
+
+// IsSuspended reports whether the account is suspended at the given time.
+func (u *User) IsSuspended(now time.Time) bool {
+	if u.SuspendedAt == nil {
+		return false
+	}
+	return u.SuspendedUntil == nil || now.Before(*u.SuspendedUntil)
+}
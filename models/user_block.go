@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Kinds of UserBlock. A block works in both directions, a mute only hides the target's content from the user.
+const (
+	BlockKindBlock = "block"
+	BlockKindMute  = "mute"
+)
+
+// UserBlock records that UserID blocked or muted TargetID.
+type UserBlock struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_user_block_pair" json:"user_id"`
+	TargetID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_user_block_pair;index" json:"target_id"`
+	Kind      string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_user_block_pair" json:"kind"`
+	User      *User     `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"User,omitempty"`
+	Target    *User     `gorm:"foreignKey:TargetID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"Target,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate sets a UUID in application code if it's not already set.
+func (b *UserBlock) BeforeCreate(tx *gorm.DB) (err error) {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
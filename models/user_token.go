@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Purposes of single-use tokens sent to users by email
+const (
+	UserTokenPasswordReset     = "password_reset"
+	UserTokenEmailVerification = "email_verification"
+	UserTokenEmailChange       = "email_change"
+)
+
+// UserToken is a single-use, expiring token delivered to the user by email.
+// Only the SHA-256 hash of the token is stored.
+type UserToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"` // Foreign Key of User
+	Purpose   string     `gorm:"not null;index" json:"purpose"`
+	Email     string     `json:"email,omitempty"` // Address being verified (email verification and email change only)
+	TokenHash string     `gorm:"not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	User      *User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"User,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// BeforeCreate sets a UUID in application code if it's not already set.
+func (ut *UserToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if ut.ID == uuid.Nil {
+		ut.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package router
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"mygram-api/database"
+	"mygram-api/helpers"
+	"mygram-api/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// fakeOIDCServer is a minimal OpenID Connect provider: discovery, authorize (auto-approve),
+// token endpoint with PKCE check and a JWKS with one RSA key.
+type fakeOIDCServer struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	clientID string
+	subject  string
+	email    string
+
+	mu    sync.Mutex
+	codes map[string]url.Values // code -> authorize request
+}
+
+func newFakeOIDCServer(t *testing.T, clientID, subject, email string) *fakeOIDCServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key failed: %v", err)
+	}
+	f := &fakeOIDCServer{key: key, clientID: clientID, subject: subject, email: email, codes: map[string]url.Values{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 f.URL,
+			"authorization_endpoint": f.URL + "/authorize",
+			"token_endpoint":         f.URL + "/token",
+			"jwks_uri":               f.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		code, _ := helpers.GenerateOpaqueToken()
+		f.mu.Lock()
+		f.codes[code] = q
+		f.mu.Unlock()
+		http.Redirect(w, r, q.Get("redirect_uri")+"?code="+code+"&state="+url.QueryEscape(q.Get("state")), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		f.mu.Lock()
+		authReq, ok := f.codes[r.PostForm.Get("code")]
+		delete(f.codes, r.PostForm.Get("code"))
+		f.mu.Unlock()
+		if !ok || helpers.PKCEChallenge(r.PostForm.Get("code_verifier")) != authReq.Get("code_challenge") {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+
+		idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            f.URL,
+			"aud":            f.clientID,
+			"sub":            f.subject,
+			"email":          f.email,
+			"email_verified": true,
+			"nonce":          authReq.Get("nonce"),
+			"iat":            time.Now().Unix(),
+			"exp":            time.Now().Add(5 * time.Minute).Unix(),
+		})
+		idToken.Header["kid"] = "fake-key"
+		signed, _ := idToken.SignedString(f.key)
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "x", "token_type": "Bearer", "id_token": signed})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []helpers.JWK{{
+			Kty: "RSA",
+			Kid: "fake-key",
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(f.key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(f.key.E)).Bytes()),
+		}}})
+	})
+
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// signInWithFakeProvider runs the whole browser round trip and returns the callback response.
+func signInWithFakeProvider(t *testing.T, router http.Handler) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/auth/oidc/fake/login", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var login struct {
+		Data struct {
+			AuthorizationURL string `json:"authorization_url"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &login))
+
+	// The "browser" follows the authorization URL; the provider redirects back with code and state
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := noRedirect.Get(login.Data.AuthorizationURL)
+	if err != nil {
+		t.Fatalf("authorize request failed: %v", err)
+	}
+	resp.Body.Close()
+	callback, _ := url.Parse(resp.Header.Get("Location"))
+
+	req = httptest.NewRequest(http.MethodGet, "/auth/oidc/fake/callback?"+callback.RawQuery, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestOIDC_SignInCreatesAndReusesLinkedUser(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	fake := newFakeOIDCServer(t, "mygram-client", "subject-123", "oidc@example.com")
+	t.Setenv("OIDC_PROVIDERS", "fake")
+	t.Setenv("OIDC_FAKE_ISSUER", fake.URL)
+	t.Setenv("OIDC_FAKE_CLIENT_ID", "mygram-client")
+	t.Setenv("OIDC_FAKE_CLIENT_SECRET", "secret")
+	t.Setenv("OIDC_FAKE_REDIRECT_URL", "http://localhost/auth/oidc/fake/callback")
+
+	testDB := setupInMemoryDB(t)
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+
+	// 1. First sign in creates the user and the identity link
+	w := signInWithFakeProvider(t, router)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	token, _ := resp["token"].(string)
+	claims, err := helpers.VerifyToken(token)
+	assert.NoError(t, err, "sign in should end in a regular MyGram token")
+
+	var user models.User
+	assert.NoError(t, testDB.First(&user, "email = ?", "oidc@example.com").Error)
+	assert.Equal(t, user.ID.String(), claims["id"])
+	assert.NotNil(t, user.EmailVerifiedAt)
+
+	// 2. Second sign in uses the same linked user
+	w = signInWithFakeProvider(t, router)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var users, identities int64
+	testDB.Model(&models.User{}).Count(&users)
+	testDB.Model(&models.UserIdentity{}).Where("provider = ? AND subject = ?", "fake", "subject-123").Count(&identities)
+	assert.Equal(t, int64(1), users)
+	assert.Equal(t, int64(1), identities)
+}
+
+func TestOIDC_CallbackRejectsUnknownState(t *testing.T) {
+	fake := newFakeOIDCServer(t, "mygram-client", "subject-123", "oidc@example.com")
+	t.Setenv("OIDC_PROVIDERS", "fake")
+	t.Setenv("OIDC_FAKE_ISSUER", fake.URL)
+
+	testDB := setupInMemoryDB(t)
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/oidc/fake/callback?code=abc&state=forged", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestOIDC_LinksOnlyVerifiedLocalAccounts(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	fake := newFakeOIDCServer(t, "mygram-client", "subject-456", "taken@example.com")
+	t.Setenv("OIDC_PROVIDERS", "fake")
+	t.Setenv("OIDC_FAKE_ISSUER", fake.URL)
+	t.Setenv("OIDC_FAKE_CLIENT_ID", "mygram-client")
+	t.Setenv("OIDC_FAKE_CLIENT_SECRET", "secret")
+	t.Setenv("OIDC_FAKE_REDIRECT_URL", "http://localhost/auth/oidc/fake/callback")
+
+	testDB := setupInMemoryDB(t)
+	local := createTestUser(t, testDB, "taken@example.com", "password123")
+	stale := models.OIDCLoginState{StateHash: "stale", Provider: "fake", Nonce: "n", CodeVerifier: "v", ExpiresAt: time.Now().Add(-time.Minute)}
+	assert.NoError(t, testDB.Create(&stale).Error)
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+
+	// 1. The local account never verified the email, so it is not taken over
+	w := signInWithFakeProvider(t, router)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var identities, states int64
+	testDB.Model(&models.UserIdentity{}).Count(&identities)
+	assert.Equal(t, int64(0), identities)
+
+	// 2. Starting a sign in removed the expired state
+	testDB.Model(&models.OIDCLoginState{}).Where("id = ?", stale.ID).Count(&states)
+	assert.Equal(t, int64(0), states)
+
+	// 3. Once verified locally, the account is linked
+	assert.NoError(t, testDB.Model(&local).Update("email_verified_at", time.Now()).Error)
+	w = signInWithFakeProvider(t, router)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var identity models.UserIdentity
+	assert.NoError(t, testDB.First(&identity, "provider = ? AND subject = ?", "fake", "subject-456").Error)
+	assert.Equal(t, local.ID, identity.UserID)
+}
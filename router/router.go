@@ -1,10 +1,13 @@
 package router
 
 import (
+	"fmt"
 	"log"
 	"mygram-api/controllers"
 	"mygram-api/database"
+	"mygram-api/helpers"
 	"mygram-api/middlewares"
+	"mygram-api/models"
 	"os"
 	"time"
 
@@ -26,12 +29,25 @@ func SetupRouter() *gin.Engine {
 		gin.SetMode(gin.DebugMode)
 	}
 	r := gin.Default()
+	// Only trust forwarded client IPs from the configured reverse proxies (none by default)
+	if err := r.SetTrustedProxies(helpers.TrustedProxies()); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 	r.Use(gin.Recovery())
 	r.Use(middlewares.CORSConfig())
 
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
+	// Public keys for verifying MyGram access tokens in other services
+	r.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(helpers.JWKSCacheMaxAge.Seconds())))
+		c.JSON(200, helpers.PublicJWKS())
+	})
+	// Uploaded photos when stored on the local filesystem
+	if local, ok := helpers.GetStorage().(*helpers.LocalStorage); ok {
+		r.Static(helpers.LocalStorageURLPath, local.Dir)
+	}
 	// Add Swagger UI endpoint
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
@@ -39,39 +55,117 @@ func SetupRouter() *gin.Engine {
 	userController := controllers.NewUserController(database.GetDB(), appLogger)
 	r.POST("/auth/register", userController.Register) // POST /users/register
 	r.POST("/auth/login", userController.Login)       // POST /users/login
+	r.POST("/auth/refresh", userController.Refresh)   // POST /auth/refresh
+
+	// Password reset (forgot is rate limited because it sends emails)
+	r.POST("/auth/password/forgot", middlewares.RateLimiterConfig(MaxRequests, RateWindow), userController.ForgotPassword)
+	r.POST("/auth/password/reset", userController.ResetPassword)
+
+	// Second step of the login when 2FA is enabled
+	r.POST("/auth/mfa/verify", middlewares.RateLimiterConfig(MaxRequests, RateWindow), userController.VerifyMFA)
+
+	// Sign in with external OpenID Connect providers
+	oidcController := controllers.NewOIDCController(database.GetDB(), appLogger, helpers.LoadOIDCProviders())
+	r.GET("/auth/oidc/:provider/login", middlewares.RateLimiterConfig(MaxRequests, RateWindow), oidcController.Login) // GET /auth/oidc/:provider/login
+	r.GET("/auth/oidc/:provider/callback", oidcController.Callback)                                                   // GET /auth/oidc/:provider/callback
+
+	// Email verification
+	r.GET("/auth/verify-email", userController.VerifyEmail) // GET /auth/verify-email?token=
 
 	// --- Authenticated Endpoints (Auth Required) ---
 	authRouter := r.Group("/")
-	authRouter.Use(middlewares.Authentication()) // Apply JWT Auth to all routes in this group
+	authRouter.Use(middlewares.Authentication()) // Apply JWT / personal access token Auth to all routes in this group
+
+	// Account management is only available to login sessions, not to personal access tokens
+	accountRouter := authRouter.Group("/")
+	accountRouter.Use(middlewares.SessionOnly())
+
+	// Logout (current session / all sessions)
+	accountRouter.POST("/auth/logout", userController.Logout)        // POST /auth/logout
+	accountRouter.POST("/auth/logout-all", userController.LogoutAll) // POST /auth/logout-all
+	accountRouter.POST("/auth/verify-email/resend", middlewares.RateLimiterConfig(MaxRequests, RateWindow), userController.ResendVerificationEmail)
 
 	// Users (PUT/DELETE require only Auth, no Authorization needed as it's self-modifying)
-	authRouter.PUT("/users", userController.Update)    // PUT /users
-	authRouter.DELETE("/users", userController.Delete) // DELETE /users
+	accountRouter.PUT("/users", userController.Update)                  // PUT /users
+	accountRouter.DELETE("/users", userController.Delete)               // DELETE /users
+	accountRouter.PUT("/users/password", userController.ChangePassword) // PUT /users/password
+	accountRouter.PUT("/users/profile", userController.UpdateProfile)   // PUT /users/profile
+
+	// Profiles
+	authRouter.GET("/users/me", middlewares.RequireScope("users:read"), userController.GetMe)             // GET /users/me
+	authRouter.GET("/users/:username", middlewares.RequireScope("users:read"), userController.GetProfile) // GET /users/:username
+
+	// Follows
+	accountRouter.POST("/users/:userID/follow", userController.Follow)                                                 // POST /users/:userID/follow
+	accountRouter.DELETE("/users/:userID/follow", userController.Unfollow)                                             // DELETE /users/:userID/follow
+	authRouter.GET("/users/:username/followers", middlewares.RequireScope("users:read"), userController.ListFollowers) // GET /users/:username/followers
+	authRouter.GET("/users/:username/following", middlewares.RequireScope("users:read"), userController.ListFollowing) // GET /users/:username/following
+	accountRouter.GET("/users/follow-requests", userController.ListFollowRequests)                                     // GET /users/follow-requests
+	accountRouter.POST("/users/follow-requests/:requestID/approve", userController.ApproveFollowRequest)               // POST /users/follow-requests/:requestID/approve
+	accountRouter.DELETE("/users/follow-requests/:requestID", userController.RejectFollowRequest)                      // DELETE /users/follow-requests/:requestID
+
+	// Blocks & mutes
+	accountRouter.POST("/users/:userID/block", userController.Block)     // POST /users/:userID/block
+	accountRouter.DELETE("/users/:userID/block", userController.Unblock) // DELETE /users/:userID/block
+	accountRouter.POST("/users/:userID/mute", userController.Mute)       // POST /users/:userID/mute
+	accountRouter.DELETE("/users/:userID/mute", userController.Unmute)   // DELETE /users/:userID/mute
+
+	// Login sessions (devices)
+	accountRouter.GET("/users/sessions", userController.ListSessions)                // GET /users/sessions
+	accountRouter.DELETE("/users/sessions/:sessionID", userController.RevokeSession) // DELETE /users/sessions/:sessionID
+
+	// Two-factor authentication (TOTP)
+	accountRouter.POST("/users/mfa/enroll", userController.EnrollMFA)   // POST /users/mfa/enroll
+	accountRouter.POST("/users/mfa/confirm", userController.ConfirmMFA) // POST /users/mfa/confirm
+	accountRouter.DELETE("/users/mfa", userController.DisableMFA)       // DELETE /users/mfa
+
+	// Personal access tokens
+	accountRouter.POST("/users/tokens", userController.CreateAccessToken)            // POST /users/tokens
+	accountRouter.GET("/users/tokens", userController.ListAccessTokens)              // GET /users/tokens
+	accountRouter.DELETE("/users/tokens/:tokenID", userController.RevokeAccessToken) // DELETE /users/tokens/:tokenID
 
 	// Photos
 	photoController := controllers.NewPhotoController(database.GetDB(), appLogger)
-	authRouter.POST("/photos", middlewares.RateLimiterConfig(MaxRequests, RateWindow), photoController.Create) // POST /photos
-	authRouter.GET("/photos", photoController.GetAll)                                                          // GET /photos
+	authRouter.POST("/photos", middlewares.RequireScope("photos:write"), middlewares.RequireVerifiedEmail(), middlewares.RateLimiterConfig(MaxRequests, RateWindow), photoController.Create) // POST /photos
+	authRouter.GET("/photos", middlewares.RequireScope("photos:read"), photoController.GetAll)                                                                                               // GET /photos
 
 	// Photos (PUT/DELETE require Auth AND Authorization)
 	photoAuthRouter := authRouter.Group("/photos")
-	photoAuthRouter.Use(middlewares.Authorization("photo"))
+	photoAuthRouter.Use(middlewares.RequireScope("photos:write"), middlewares.Authorization("photo"))
 	{
 		photoAuthRouter.PUT("/:photoID", photoController.Update)    // PUT /photos/:photoID
 		photoAuthRouter.DELETE("/:photoID", photoController.Delete) // DELETE /photos/:photoID
 	}
 
+	authRouter.POST("/photos/:photoID/view", middlewares.RequireScope("photos:read"), photoController.RecordView) // POST /photos/:photoID/view
+	authRouter.POST("/photos/:photoID/like", middlewares.RequireScope("photos:write"), photoController.Like)      // POST /photos/:photoID/like
+	authRouter.DELETE("/photos/:photoID/like", middlewares.RequireScope("photos:write"), photoController.Unlike)  // DELETE /photos/:photoID/like
+	authRouter.GET("/photos/:photoID/likes", middlewares.RequireScope("photos:read"), photoController.ListLikes)  // GET /photos/:photoID/likes
+	authRouter.GET("/photos/:photoID", middlewares.RequireScope("photos:read"), photoController.GetByID)          // GET /photos/:photoID
+
+	// Home feed & explore
+	feedController := controllers.NewFeedController(database.GetDB(), appLogger)
+	authRouter.GET("/feed", middlewares.RequireScope("photos:read"), feedController.GetFeed)       // GET /feed
+	authRouter.GET("/explore", middlewares.RequireScope("photos:read"), feedController.GetExplore) // GET /explore
+
 	// Comments
 	commentController := controllers.NewCommentController(database.GetDB(), appLogger)
-	authRouter.POST("/comments", middlewares.RateLimiterConfig(MaxRequests, RateWindow), commentController.Create) // POST /comments
-	authRouter.GET("/comments", commentController.GetAll)                                                          // GET /comments
+	authRouter.POST("/comments", middlewares.RequireScope("comments:write"), middlewares.RequireVerifiedEmail(), middlewares.RateLimiterConfig(MaxRequests, RateWindow), commentController.Create) // POST /comments
+	authRouter.GET("/comments", middlewares.RequireScope("comments:read"), commentController.GetAll)                                                                                               // GET /comments
+
+	authRouter.POST("/comments/reply/:parentCommentID", middlewares.RequireScope("comments:write"), middlewares.RequireVerifiedEmail(), middlewares.RateLimiterConfig(MaxRequests, RateWindow), commentController.CreateReply)
+	authRouter.GET("/comments/:commentID/replies", middlewares.RequireScope("comments:read"), commentController.GetReplies)
+	authRouter.GET("/comments/:commentID/thread", middlewares.RequireScope("comments:read"), commentController.GetThread)       // GET /comments/:commentID/thread
+	authRouter.GET("/photos/:photoID/comments", middlewares.RequireScope("comments:read"), commentController.ListPhotoComments) // GET /photos/:photoID/comments
+	authRouter.GET("/comments/:commentID", middlewares.RequireScope("comments:read"), commentController.GetByID)                // GET /comments/:commentID
 
-	authRouter.POST("/comments/reply/:parentCommentID", middlewares.RateLimiterConfig(MaxRequests, RateWindow), commentController.CreateReply)
-	authRouter.GET("/comments/:parentCommentID/replies", commentController.GetReplies)
+	// Comment reactions
+	authRouter.POST("/comments/:commentID/reactions", middlewares.RequireScope("comments:write"), commentController.AddReaction)            // POST /comments/:commentID/reactions
+	authRouter.DELETE("/comments/:commentID/reactions/:type", middlewares.RequireScope("comments:write"), commentController.RemoveReaction) // DELETE /comments/:commentID/reactions/:type
 
 	// Comments (PUT/DELETE require Auth AND Authorization)
 	commentAuthRouter := authRouter.Group("/comments")
-	commentAuthRouter.Use(middlewares.Authorization("comment"))
+	commentAuthRouter.Use(middlewares.RequireScope("comments:write"), middlewares.Authorization("comment"))
 	{
 		commentAuthRouter.PUT("/:commentID", commentController.Update)    // PUT /comments/:commentID
 		commentAuthRouter.DELETE("/:commentID", commentController.Delete) // DELETE /comments/:commentID
@@ -79,16 +173,31 @@ func SetupRouter() *gin.Engine {
 
 	// SocialMedias
 	socialMediaController := controllers.NewSocialMediaController(database.GetDB(), appLogger)
-	authRouter.POST("/socialmedias", socialMediaController.Create) // POST /socialmedias
-	authRouter.GET("/socialmedias", socialMediaController.GetAll)  // GET /socialmedias
+	authRouter.POST("/socialmedias", middlewares.RequireScope("socialmedias:write"), socialMediaController.Create)               // POST /socialmedias
+	authRouter.GET("/socialmedias", middlewares.RequireScope("socialmedias:read"), socialMediaController.GetAll)                 // GET /socialmedias
+	authRouter.GET("/socialmedias/:socialmediaID", middlewares.RequireScope("socialmedias:read"), socialMediaController.GetByID) // GET /socialmedias/:socialmediaID
 
 	// SocialMedias (PUT/DELETE require Auth AND Authorization)
 	smAuthRouter := authRouter.Group("/socialmedias")
-	smAuthRouter.Use(middlewares.Authorization("socialmedia"))
+	smAuthRouter.Use(middlewares.RequireScope("socialmedias:write"), middlewares.Authorization("socialmedia"))
 	{
 		smAuthRouter.PUT("/:socialmediaID", socialMediaController.Update)    // PUT /socialmedias/:socialMediaID
 		smAuthRouter.DELETE("/:socialmediaID", socialMediaController.Delete) // DELETE /socialmedias/:socialMediaID
 	}
 
+	// Admin (login session with admin role only)
+	adminController := controllers.NewAdminController(database.GetDB(), appLogger)
+	adminRouter := accountRouter.Group("/admin")
+	adminRouter.Use(middlewares.RequireRole(models.RoleAdmin))
+	{
+		adminRouter.GET("/users", adminController.ListUsers)                                  // GET /admin/users?q=&page=&limit=
+		adminRouter.GET("/users/:userID", adminController.GetUser)                            // GET /admin/users/:userID
+		adminRouter.PUT("/users/:userID/role", adminController.SetUserRole)                   // PUT /admin/users/:userID/role
+		adminRouter.POST("/users/:userID/suspend", adminController.SuspendUser)               // POST /admin/users/:userID/suspend
+		adminRouter.DELETE("/users/:userID/suspend", adminController.UnsuspendUser)           // DELETE /admin/users/:userID/suspend
+		adminRouter.POST("/users/:userID/password-reset", adminController.ForcePasswordReset) // POST /admin/users/:userID/password-reset
+		adminRouter.DELETE("/users/:userID", adminController.DeleteUser)                      // DELETE /admin/users/:userID
+	}
+
 	return r
 }
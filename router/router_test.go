@@ -3,13 +3,25 @@ package router
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"mime/multipart"
 	"mygram-api/database"
+	"mygram-api/dto"
 	"mygram-api/helpers"
 	"mygram-api/models"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -28,13 +40,21 @@ func (m *TokenMock) CreateToken(userID uuid.UUID, email string) (string, error)
 	return args.String(0), args.Error(1)
 }
 
+// defaultCreateTokenFunc keeps the real token implementation so tests that mock it can restore it.
+var defaultCreateTokenFunc = helpers.CreateTokenFunc
+
 func setupInMemoryDB(t *testing.T) *gorm.DB {
 	// in-memory sqlite
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	if err != nil {
 		t.Fatalf("failed to open in-memory db: %v", err)
 	}
-	if err := db.AutoMigrate(&models.User{}); err != nil {
+	// every new connection would get its own empty in-memory database
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.User{}, &models.RefreshToken{}, &models.UserToken{}, &models.MFARecoveryCode{}, &models.MFAChallenge{},
+		&models.UserIdentity{}, &models.OIDCLoginState{}, &models.PersonalAccessToken{}, &models.Session{},
+		&models.Photo{}, &models.Comment{}, &models.SocialMedia{}, &models.AuditLog{}, &models.Follow{}, &models.UserBlock{}, &models.PhotoLike{}, &models.CommentReaction{}); err != nil {
 		t.Fatalf("auto migrate failed: %v", err)
 	}
 	return db
@@ -71,9 +91,10 @@ func TestLogin_Success(t *testing.T) {
 	tokenMock.On("CreateToken", mock.Anything, "user@example.com").Return("mocked-token", nil)
 
 	// override helpers.CreateTokenFunc to the mock's method
-	helpers.CreateTokenFunc = func(userID uuid.UUID, email string) (string, error) {
+	helpers.CreateTokenFunc = func(userID uuid.UUID, email string, opts ...helpers.TokenOption) (string, error) {
 		return tokenMock.CreateToken(userID, email)
 	}
+	defer func() { helpers.CreateTokenFunc = defaultCreateTokenFunc }()
 
 	// init router and call endpoint
 	router := SetupRouter()
@@ -98,6 +119,7 @@ func TestLogin_Success(t *testing.T) {
 	tokenVal, ok := resp["token"].(string)
 	assert.True(t, ok, "token should be a string in response")
 	assert.Equal(t, "mocked-token", tokenVal)
+	assert.NotEmpty(t, resp["refresh_token"], "refresh token should be returned")
 
 	// assert mock called
 	tokenMock.AssertCalled(t, "CreateToken", mock.Anything, "user@example.com")
@@ -149,14 +171,1800 @@ func TestLogin_InvalidPassword(t *testing.T) {
 	assert.Equal(t, "Invalid email or password", resp["message"])
 }
 
-func TestSetupRouter_SwaggerEndpointExists(t *testing.T) {
-	t.Parallel()
+func TestLogin_UnknownEmailLooksLikeWrongPassword(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
 
-	r := SetupRouter()
-	req := httptest.NewRequest("GET", "/swagger/index.html", nil)
+	testDB := setupInMemoryDB(t)
+	createTestUser(t, testDB, "known@example.com", "correctpassword")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+
+	wrongPassword := postJSON(router, "/auth/login", map[string]string{"email": "known@example.com", "password": "wrongpassword"}, "")
+	unknownEmail := postJSON(router, "/auth/login", map[string]string{"email": "nobody@example.com", "password": "wrongpassword"}, "")
+
+	assert.Equal(t, http.StatusUnauthorized, wrongPassword.Code)
+	assert.Equal(t, wrongPassword.Code, unknownEmail.Code)
+	assert.Equal(t, wrongPassword.Body.String(), unknownEmail.Body.String())
+}
+
+func TestLogin_IgnoresSpoofedForwardedFor(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	createTestUser(t, testDB, "proxy@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+
+	// 1. Login with a forged X-Forwarded-For from a client that is not a trusted proxy
+	bodyBytes, _ := json.Marshal(map[string]string{"email": "proxy@example.com", "password": "password123"})
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
 	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
 
-	// We just assert that the route isn't 404. The swagger handler serves static UI.
-	assert.NotEqual(t, http.StatusNotFound, w.Code, "swagger endpoint should be registered")
+	// 2. The session records the connection address, not the header
+	var session models.Session
+	assert.NoError(t, testDB.First(&session).Error)
+	assert.Equal(t, "192.0.2.1", session.IPAddress)
+}
+
+// postJSON sends a JSON body to the router and returns the recorded response.
+func postJSON(router http.Handler, path string, body any, token string) *httptest.ResponseRecorder {
+	bodyBytes, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// createTestUser inserts a user with the given email and password into the test DB.
+func createTestUser(t *testing.T, db *gorm.DB, email, password string) models.User {
+	hashed, err := helpers.HashPassword(password)
+	if err != nil {
+		t.Fatalf("hash password failed: %v", err)
+	}
+	user := models.User{
+		Username: email,
+		Email:    email,
+		Password: hashed,
+		Age:      20,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user failed: %v", err)
+	}
+	return user
+}
+
+func TestRefresh_RotatesTokenAndDetectsReuse(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	createTestUser(t, testDB, "refresh@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+
+	router := SetupRouter()
+
+	// 1. Login to get the first refresh token
+	w := postJSON(router, "/auth/login", map[string]string{
+		"email":    "refresh@example.com",
+		"password": "password123",
+	}, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var login map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &login))
+	firstRefresh, _ := login["refresh_token"].(string)
+	assert.NotEmpty(t, firstRefresh)
+
+	// 2. Exchange it for a new pair
+	w = postJSON(router, "/auth/refresh", map[string]string{"refresh_token": firstRefresh}, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var refreshed map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &refreshed))
+	secondRefresh, _ := refreshed["refresh_token"].(string)
+	assert.NotEmpty(t, refreshed["token"])
+	assert.NotEmpty(t, secondRefresh)
+	assert.NotEqual(t, firstRefresh, secondRefresh, "refresh token should be rotated")
+
+	// 3. Reusing the first token is rejected and revokes the whole family
+	w = postJSON(router, "/auth/refresh", map[string]string{"refresh_token": firstRefresh}, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = postJSON(router, "/auth/refresh", map[string]string{"refresh_token": secondRefresh}, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "successor token should be revoked after reuse")
+}
+
+func TestLogout_RevokesSessionRefreshToken(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	createTestUser(t, testDB, "logout@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+
+	router := SetupRouter()
+
+	w := postJSON(router, "/auth/login", map[string]string{
+		"email":    "logout@example.com",
+		"password": "password123",
+	}, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var login map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &login))
+	token, _ := login["token"].(string)
+	refreshToken, _ := login["refresh_token"].(string)
+
+	w = postJSON(router, "/auth/logout", nil, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The logged out session can no longer be refreshed
+	w = postJSON(router, "/auth/refresh", map[string]string{"refresh_token": refreshToken}, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestResetPassword_ConsumesTokenOnce(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	user := createTestUser(t, testDB, "reset@example.com", "oldpassword")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+
+	token, err := helpers.CreateUserToken(testDB, user.ID, models.UserTokenPasswordReset, time.Hour)
+	assert.NoError(t, err)
+
+	router := SetupRouter()
+
+	body := map[string]string{"token": token, "password": "newpassword"}
+	w := postJSON(router, "/auth/password/reset", body, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Token is single-use
+	w = postJSON(router, "/auth/password/reset", body, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Old password no longer works, the new one does
+	w = postJSON(router, "/auth/login", map[string]string{"email": "reset@example.com", "password": "oldpassword"}, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = postJSON(router, "/auth/login", map[string]string{"email": "reset@example.com", "password": "newpassword"}, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestChangePassword_RevokesOtherSessionsAndReturnsToken(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	createTestUser(t, testDB, "change@example.com", "oldpassword")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+
+	changePassword := func(token string, body map[string]string) *httptest.ResponseRecorder {
+		bodyBytes, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPut, "/users/password", bytes.NewBuffer(bodyBytes))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	listSessions := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/users/sessions", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	phoneToken := loginToken(t, router, "change@example.com", "oldpassword")
+	laptopToken := loginToken(t, router, "change@example.com", "oldpassword")
+
+	// 1. Wrong current password is rejected and nothing changes
+	w := changePassword(laptopToken, map[string]string{"current_password": "wrong", "new_password": "newpassword"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, http.StatusOK, listSessions(phoneToken))
+
+	// 2. Change the password from the laptop
+	w = changePassword(laptopToken, map[string]string{"current_password": "oldpassword", "new_password": "newpassword"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.NotEmpty(t, resp.Data.Token)
+
+	// 3. The phone session is logged out, the returned token works for the laptop session
+	assert.Equal(t, http.StatusUnauthorized, listSessions(phoneToken))
+	assert.Equal(t, http.StatusOK, listSessions(resp.Data.Token))
+
+	// 4. Only the new password logs in
+	w = postJSON(router, "/auth/login", map[string]string{"email": "change@example.com", "password": "oldpassword"}, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	loginToken(t, router, "change@example.com", "newpassword")
+}
+
+func TestVerifyEmail_AppliesEmailChangeOnlyAfterConfirmation(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	user := createTestUser(t, testDB, "old@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+
+	router := SetupRouter()
+
+	w := postJSON(router, "/auth/login", map[string]string{"email": "old@example.com", "password": "password123"}, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var login map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &login))
+	token, _ := login["token"].(string)
+
+	// 1. Request the email change: the old address stays active
+	bodyBytes, _ := json.Marshal(map[string]string{"email": "new@example.com", "username": user.Username})
+	req := httptest.NewRequest(http.MethodPut, "/users", bytes.NewBuffer(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var stored models.User
+	assert.NoError(t, testDB.First(&stored, "id = ?", user.ID).Error)
+	assert.Equal(t, "old@example.com", stored.Email)
+	assert.Nil(t, stored.EmailVerifiedAt)
+
+	// 2. Confirm the new address (the emailed token is not observable, so issue a fresh one)
+	verifyToken, err := helpers.CreateEmailChangeToken(testDB, user.ID, "new@example.com", time.Hour)
+	assert.NoError(t, err)
+
+	// 3. Resending the verification of the old address keeps the email change link valid
+	req = httptest.NewRequest(http.MethodPost, "/auth/verify-email/resend", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/auth/verify-email?token="+verifyToken, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.NoError(t, testDB.First(&stored, "id = ?", user.ID).Error)
+	assert.Equal(t, "new@example.com", stored.Email)
+	assert.NotNil(t, stored.EmailVerifiedAt)
+}
+
+func TestMFA_LoginRequiresSecondFactor(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	createTestUser(t, testDB, "mfa@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+
+	router := SetupRouter()
+	credentials := map[string]string{"email": "mfa@example.com", "password": "password123"}
+
+	w := postJSON(router, "/auth/login", credentials, "")
+	var login map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &login))
+	token, _ := login["token"].(string)
+
+	// 1. Enroll and confirm with a code from the "authenticator app"
+	w = postJSON(router, "/users/mfa/enroll", nil, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var enroll struct {
+		Data struct {
+			Secret string `json:"secret"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &enroll))
+
+	code, err := helpers.TOTPCode(enroll.Data.Secret, time.Now())
+	assert.NoError(t, err)
+	w = postJSON(router, "/users/mfa/confirm", map[string]string{"code": code}, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var confirm struct {
+		Data struct {
+			RecoveryCodes []string `json:"recovery_codes"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &confirm))
+	assert.Len(t, confirm.Data.RecoveryCodes, 10)
+
+	// 2. Password alone now only yields an mfa_pending token, which the API rejects
+	w = postJSON(router, "/auth/login", credentials, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var pending map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &pending))
+	assert.Equal(t, true, pending["mfa_required"])
+	assert.Nil(t, pending["token"])
+	mfaToken, _ := pending["mfa_token"].(string)
+
+	w = postJSON(router, "/users/mfa/enroll", nil, mfaToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// 3. A recovery code completes the login, and only once
+	recovery := confirm.Data.RecoveryCodes[0]
+	w = postJSON(router, "/auth/mfa/verify", map[string]string{"mfa_token": mfaToken, "code": recovery}, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "refresh_token")
+
+	w = postJSON(router, "/auth/mfa/verify", map[string]string{"mfa_token": mfaToken, "code": recovery}, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// 4. The mfa_pending token is single-use, even with another valid code
+	w = postJSON(router, "/auth/mfa/verify", map[string]string{"mfa_token": mfaToken, "code": confirm.Data.RecoveryCodes[1]}, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// 5. After MFA_MAX_ATTEMPTS wrong codes the token is dead, a valid code no longer helps
+	w = postJSON(router, "/auth/login", credentials, "")
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &pending))
+	mfaToken, _ = pending["mfa_token"].(string)
+	for i := 0; i < 5; i++ {
+		w = postJSON(router, "/auth/mfa/verify", map[string]string{"mfa_token": mfaToken, "code": "wrong-code"}, "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	assert.Contains(t, w.Body.String(), "log in again")
+	w = postJSON(router, "/auth/mfa/verify", map[string]string{"mfa_token": mfaToken, "code": confirm.Data.RecoveryCodes[2]}, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestPersonalAccessToken_ScopesAndRevocation(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	createTestUser(t, testDB, "pat@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+
+	w := postJSON(router, "/auth/login", map[string]string{"email": "pat@example.com", "password": "password123"}, "")
+	var login map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &login))
+	jwtToken, _ := login["token"].(string)
+
+	// 1. Unknown scopes are rejected
+	w = postJSON(router, "/users/tokens", map[string]any{"name": "bad", "scopes": []string{"admin:all"}}, jwtToken)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 2. Create a read-only token
+	w = postJSON(router, "/users/tokens", map[string]any{"name": "script", "scopes": []string{"photos:read"}}, jwtToken)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created struct {
+		Data struct {
+			ID    string `json:"id"`
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	pat := created.Data.Token
+	assert.True(t, strings.HasPrefix(pat, helpers.PersonalAccessTokenPrefix))
+
+	// 3. Scope enforcement and no access to account management
+	w = postJSON(router, "/photos", map[string]string{"title": "x", "photo_url": "https://example.com/x.jpg"}, pat)
+	assert.Equal(t, http.StatusForbidden, w.Code, "photos:write is not granted")
+
+	req := httptest.NewRequest(http.MethodGet, "/users/tokens", nil)
+	req.Header.Set("Authorization", "Bearer "+pat)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// The owner's profile (email, role, 2FA status) needs users:read
+	req = httptest.NewRequest(http.MethodGet, "/users/me", nil)
+	req.Header.Set("Authorization", "Bearer "+pat)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code, "users:read is not granted")
+
+	// 4. Revoked tokens no longer authenticate
+	req = httptest.NewRequest(http.MethodDelete, "/users/tokens/"+created.Data.ID, nil)
+	req.Header.Set("Authorization", "Bearer "+jwtToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = postJSON(router, "/photos", map[string]string{"title": "x", "photo_url": "https://example.com/x.jpg"}, pat)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestSessions_ListAndRevokeDevice(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	createTestUser(t, testDB, "sessions@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+
+	login := func() string {
+		w := postJSON(router, "/auth/login", map[string]string{"email": "sessions@example.com", "password": "password123"}, "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]any
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		token, _ := resp["token"].(string)
+		return token
+	}
+	phoneToken := login()
+	laptopToken := login()
+
+	// 1. Both devices are listed, the caller's own session is marked as current
+	req := httptest.NewRequest(http.MethodGet, "/users/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+laptopToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var list struct {
+		Data []map[string]any `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.Data, 2)
+
+	var phoneSessionID string
+	for _, session := range list.Data {
+		if session["current"] != true {
+			phoneSessionID, _ = session["id"].(string)
+		}
+	}
+	assert.NotEmpty(t, phoneSessionID)
+
+	// 2. Revoke the phone session from the laptop
+	req = httptest.NewRequest(http.MethodDelete, "/users/sessions/"+phoneSessionID, nil)
+	req.Header.Set("Authorization", "Bearer "+laptopToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 3. The phone's access token is rejected, the laptop's still works
+	req = httptest.NewRequest(http.MethodGet, "/users/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+phoneToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/users/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+laptopToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestSessions_HidesSessionsWithExpiredRefreshToken(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	user := createTestUser(t, testDB, "stale@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+
+	loginToken(t, router, "stale@example.com", "password123")
+	token := loginToken(t, router, "stale@example.com", "password123")
+	claims, err := helpers.VerifyToken(token)
+	assert.NoError(t, err)
+
+	// The other device's refresh token ran out: that session can no longer be used
+	assert.NoError(t, testDB.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ?", user.ID, claims["sid"]).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var list struct {
+		Data []map[string]any `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	if assert.Len(t, list.Data, 1) {
+		assert.Equal(t, claims["sid"], list.Data[0]["id"])
+		assert.Equal(t, true, list.Data[0]["current"])
+	}
+}
+
+// loginToken logs the user in and returns the access token.
+func loginToken(t *testing.T, router http.Handler, email, password string) string {
+	w := postJSON(router, "/auth/login", map[string]string{"email": email, "password": password}, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	token, _ := resp["token"].(string)
+	return token
+}
+
+func TestAuthorization_ModeratorBypassIsAudited(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	owner := createTestUser(t, testDB, "owner@example.com", "password123")
+	createTestUser(t, testDB, "other@example.com", "password123")
+	moderator := createTestUser(t, testDB, "mod@example.com", "password123")
+	assert.NoError(t, testDB.Model(&moderator).Update("role", models.RoleModerator).Error)
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+
+	social := models.SocialMedia{Name: "Instagram", SocialMediaUrl: "https://instagram.com/owner", UserID: owner.ID}
+	assert.NoError(t, testDB.Create(&social).Error)
+
+	update := func(token string) int {
+		body, _ := json.Marshal(map[string]string{"name": "Removed", "social_media_url": "https://example.com/removed"})
+		req := httptest.NewRequest(http.MethodPut, "/socialmedias/"+social.ID.String(), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Regular users still cannot touch other users' resources
+	assert.Equal(t, http.StatusForbidden, update(loginToken(t, router, "other@example.com", "password123")))
+
+	var count int64
+	testDB.Model(&models.AuditLog{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	// Moderators can, and the bypass is audited
+	assert.Equal(t, http.StatusOK, update(loginToken(t, router, "mod@example.com", "password123")))
+
+	var entry models.AuditLog
+	assert.NoError(t, testDB.First(&entry).Error)
+	assert.Equal(t, moderator.ID, entry.ActorID)
+	assert.Equal(t, models.RoleModerator, entry.ActorRole)
+	assert.Equal(t, "socialmedia", entry.ResourceType)
+	assert.Equal(t, social.ID.String(), entry.ResourceID)
+	assert.Equal(t, owner.ID, *entry.TargetUserID)
+}
+
+func TestAdmin_RequiresAdminRole(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	admin := createTestUser(t, testDB, "admin@example.com", "password123")
+	assert.NoError(t, testDB.Model(&admin).Update("role", models.RoleAdmin).Error)
+	user := createTestUser(t, testDB, "plain@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+
+	setRole := func(token string) int {
+		body, _ := json.Marshal(map[string]string{"role": "moderator"})
+		req := httptest.NewRequest(http.MethodPut, "/admin/users/"+user.ID.String()+"/role", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, setRole(loginToken(t, router, "plain@example.com", "password123")))
+	assert.Equal(t, http.StatusOK, setRole(loginToken(t, router, "admin@example.com", "password123")))
+
+	var updated models.User
+	assert.NoError(t, testDB.First(&updated, "id = ?", user.ID).Error)
+	assert.Equal(t, models.RoleModerator, updated.Role)
+}
+
+func TestBootstrapAdmin_PromotesVerifiedAdminEmailOnce(t *testing.T) {
+	testDB := setupInMemoryDB(t)
+	owner := createTestUser(t, testDB, "owner@example.com", "password123")
+	other := createTestUser(t, testDB, "other@example.com", "password123")
+
+	// 1. Without ADMIN_EMAIL nothing happens
+	t.Setenv("ADMIN_EMAIL", "")
+	promoted, err := helpers.BootstrapAdmin(testDB)
+	assert.NoError(t, err)
+	assert.False(t, promoted)
+
+	// 2. The account has to verify its email first
+	t.Setenv("ADMIN_EMAIL", "owner@example.com")
+	promoted, err = helpers.BootstrapAdmin(testDB)
+	assert.Error(t, err)
+	assert.False(t, promoted)
+
+	assert.NoError(t, testDB.Model(&owner).Update("email_verified_at", time.Now()).Error)
+	promoted, err = helpers.BootstrapAdmin(testDB)
+	assert.NoError(t, err)
+	assert.True(t, promoted)
+	assert.NoError(t, testDB.First(&owner, "id = ?", owner.ID).Error)
+	assert.Equal(t, models.RoleAdmin, owner.Role)
+
+	// 3. Once an admin exists, changing ADMIN_EMAIL promotes nobody else
+	assert.NoError(t, testDB.Model(&other).Update("email_verified_at", time.Now()).Error)
+	t.Setenv("ADMIN_EMAIL", "other@example.com")
+	promoted, err = helpers.BootstrapAdmin(testDB)
+	assert.NoError(t, err)
+	assert.False(t, promoted)
+	assert.NoError(t, testDB.First(&other, "id = ?", other.ID).Error)
+	assert.Equal(t, models.RoleUser, other.Role)
+}
+
+func TestAdmin_SuspendBlocksTokensAndLogin(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	admin := createTestUser(t, testDB, "root@example.com", "password123")
+	assert.NoError(t, testDB.Model(&admin).Update("role", models.RoleAdmin).Error)
+	user := createTestUser(t, testDB, "spammer@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+
+	adminToken := loginToken(t, router, "root@example.com", "password123")
+	userToken := loginToken(t, router, "spammer@example.com", "password123")
+
+	get := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Search finds the user, detail includes resource counts
+	w := get("/admin/users?q=SPAM", adminToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"total":1`)
+
+	w = get("/admin/users/"+user.ID.String(), adminToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"photo_count":0`)
+
+	// Suspend with reason and end date
+	until := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	w = postJSON(router, "/admin/users/"+user.ID.String()+"/suspend", map[string]string{"reason": "Spam", "until": until}, adminToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Existing token and new logins are rejected with the reason
+	w = get("/users/sessions", userToken)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "Reason: Spam")
+
+	w = postJSON(router, "/auth/login", map[string]string{"email": "spammer@example.com", "password": "password123"}, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "suspended until")
+
+	// Unsuspend restores access
+	req := httptest.NewRequest(http.MethodDelete, "/admin/users/"+user.ID.String()+"/suspend", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	loginToken(t, router, "spammer@example.com", "password123")
+
+	// Admins cannot lock themselves out with a forced password reset
+	w = postJSON(router, "/admin/users/"+admin.ID.String()+"/password-reset", nil, adminToken)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	loginToken(t, router, "root@example.com", "password123")
+}
+
+func TestAdmin_ListUsersSearchMatchesWildcardsLiterally(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	admin := createTestUser(t, testDB, "admin@example.com", "password123")
+	assert.NoError(t, testDB.Model(&admin).Update("role", models.RoleAdmin).Error)
+	createTestUser(t, testDB, "snake_case@example.com", "password123")
+	createTestUser(t, testDB, "snakeXcase@example.com", "password123")
+	createTestUser(t, testDB, "100%real@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+	token := loginToken(t, router, "admin@example.com", "password123")
+
+	search := func(q string) int64 {
+		req := httptest.NewRequest(http.MethodGet, "/admin/users?q="+url.QueryEscape(q), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Data dto.AdminUserListResponse `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Data.Total
+	}
+
+	assert.Equal(t, int64(1), search("snake_case"))
+	assert.Equal(t, int64(1), search("%"))
+	assert.Equal(t, int64(2), search("snake"))
+}
+
+func TestProfiles_PublicProfileHidesPrivateFields(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	owner := createTestUser(t, testDB, "profile@example.com", "password123")
+	assert.NoError(t, testDB.Model(&owner).Updates(map[string]any{"username": "profile_owner", "display_name": "Profile Owner", "bio": "Hello"}).Error)
+	assert.NoError(t, testDB.Create(&models.Photo{Title: "x", PhotoUrl: "https://example.com/x.jpg", UserID: owner.ID}).Error)
+	createTestUser(t, testDB, "viewer@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+
+	get := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	viewerToken := loginToken(t, router, "viewer@example.com", "password123")
+
+	w := get("/users/profile_owner", viewerToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"display_name":"Profile Owner"`)
+	assert.Contains(t, w.Body.String(), `"photo_count":1`)
+	assert.NotContains(t, w.Body.String(), "profile@example.com")
+	assert.NotContains(t, w.Body.String(), "password")
+
+	w = get("/users/does-not-exist", viewerToken)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = get("/users/me", viewerToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"email":"viewer@example.com"`)
+}
+
+func TestProfiles_UpdateChangesOnlySentFields(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	user := createTestUser(t, testDB, "partial@example.com", "password123")
+	assert.NoError(t, testDB.Model(&user).Updates(map[string]any{
+		"display_name": "Partial", "bio": "Old bio", "website": "https://old.example.com", "is_private": true,
+	}).Error)
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+	token := loginToken(t, router, "partial@example.com", "password123")
+
+	update := func(body string) int {
+		req := httptest.NewRequest(http.MethodPut, "/users/profile", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 1. Only the bio changes, omitted fields keep their value
+	assert.Equal(t, http.StatusOK, update(`{"bio":"New bio"}`))
+	var stored models.User
+	assert.NoError(t, testDB.First(&stored, "id = ?", user.ID).Error)
+	assert.Equal(t, "New bio", stored.Bio)
+	assert.Equal(t, "Partial", stored.DisplayName)
+	assert.Equal(t, "https://old.example.com", stored.Website)
+	assert.True(t, stored.IsPrivate)
+
+	// 2. An empty string clears a field, false is stored as well
+	assert.Equal(t, http.StatusOK, update(`{"website":"","is_private":false}`))
+	assert.NoError(t, testDB.First(&stored, "id = ?", user.ID).Error)
+	assert.Empty(t, stored.Website)
+	assert.False(t, stored.IsPrivate)
+	assert.Equal(t, "New bio", stored.Bio)
+
+	// 3. Present fields are still validated
+	assert.Equal(t, http.StatusBadRequest, update(`{"avatar_url":"not a url"}`))
+}
+
+func TestProfiles_PrivateProfileHidesPhotos(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	owner := createTestUser(t, testDB, "hidden@example.com", "password123")
+	assert.NoError(t, testDB.Model(&owner).Update("is_private", true).Error)
+	follower := createTestUser(t, testDB, "friend@example.com", "password123")
+	createTestUser(t, testDB, "stranger@example.com", "password123")
+	assert.NoError(t, testDB.Create(&models.Follow{FollowerID: follower.ID, FollowingID: owner.ID, Status: models.FollowStatusAccepted}).Error)
+
+	photo := models.Photo{Title: "secret", PhotoUrl: "https://example.com/secret.jpg", UserID: owner.ID}
+	assert.NoError(t, testDB.Create(&photo).Error)
+	comment := models.Comment{UserID: owner.ID, PhotoID: photo.ID, Message: "private note"}
+	assert.NoError(t, testDB.Create(&comment).Error)
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+
+	get := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	paths := []string{
+		"/photos/" + photo.ID.String() + "/comments",
+		"/photos/" + photo.ID.String() + "/likes",
+		"/comments/" + comment.ID.String() + "?include=photo",
+	}
+
+	// 1. Non-followers get neither the photo in lists nor anything hanging off it
+	strangerToken := loginToken(t, router, "stranger@example.com", "password123")
+	w := get("/photos?user_id="+owner.ID.String(), strangerToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), photo.ID.String())
+	for _, path := range paths {
+		assert.Equal(t, http.StatusForbidden, get(path, strangerToken).Code, path)
+	}
+
+	// 2. Accepted followers see everything
+	followerToken := loginToken(t, router, "friend@example.com", "password123")
+	assert.Contains(t, get("/photos?user_id="+owner.ID.String(), followerToken).Body.String(), photo.ID.String())
+	for _, path := range paths {
+		assert.Equal(t, http.StatusOK, get(path, followerToken).Code, path)
+	}
+
+	// 3. A block hides a public profile's photos as if they did not exist
+	assert.NoError(t, testDB.Model(&owner).Update("is_private", false).Error)
+	assert.NoError(t, testDB.Create(&models.UserBlock{UserID: owner.ID, TargetID: follower.ID, Kind: models.BlockKindBlock}).Error)
+	assert.NotContains(t, get("/photos", followerToken).Body.String(), photo.ID.String())
+	for _, path := range paths {
+		assert.Equal(t, http.StatusNotFound, get(path, followerToken).Code, path)
+	}
+	assert.Contains(t, get("/photos", strangerToken).Body.String(), photo.ID.String())
+}
+
+func TestFollows_PublicPrivateAndMutual(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	alice := createTestUser(t, testDB, "alice@example.com", "password123")
+	bob := createTestUser(t, testDB, "bob@example.com", "password123")
+	assert.NoError(t, testDB.Model(&bob).Update("is_private", true).Error)
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+
+	aliceToken := loginToken(t, router, "alice@example.com", "password123")
+	bobToken := loginToken(t, router, "bob@example.com", "password123")
+
+	get := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 1. Bob follows public Alice directly (twice: idempotent)
+	w := postJSON(router, "/users/"+alice.ID.String()+"/follow", nil, bobToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"accepted"`)
+	w = postJSON(router, "/users/"+alice.ID.String()+"/follow", nil, bobToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 2. Alice following private Bob creates a pending request
+	w = postJSON(router, "/users/"+bob.ID.String()+"/follow", nil, aliceToken)
+	assert.Contains(t, w.Body.String(), `"status":"pending"`)
+
+	w = get("/users/"+bob.Username+"/followers", aliceToken)
+	assert.Equal(t, http.StatusForbidden, w.Code, "private follower list is hidden until approved")
+
+	// 3. Bob approves the request
+	w = get("/users/follow-requests", bobToken)
+	var requests struct {
+		Data []map[string]any `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &requests))
+	assert.Len(t, requests.Data, 1)
+	requestID, _ := requests.Data[0]["id"].(string)
+
+	w = postJSON(router, "/users/follow-requests/"+requestID+"/approve", nil, bobToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 4. Now they follow each other
+	w = get("/users/"+bob.Username, aliceToken)
+	assert.Contains(t, w.Body.String(), `"mutual":true`)
+	assert.Contains(t, w.Body.String(), `"follower_count":1`)
+
+	w = get("/users/"+bob.Username+"/followers", aliceToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"total":1`)
+
+	var count int64
+	testDB.Model(&models.Follow{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestFeed_FollowedPhotosNewestFirstWithCursor(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	alice := createTestUser(t, testDB, "alice@example.com", "password123")
+	bob := createTestUser(t, testDB, "bob@example.com", "password123")
+	carol := createTestUser(t, testDB, "carol@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+	aliceToken := loginToken(t, router, "alice@example.com", "password123")
+
+	w := postJSON(router, "/users/"+bob.ID.String()+"/follow", nil, aliceToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Bob: 3 photos, Alice: 1 (own photos are in the feed), Carol: 1 (not followed)
+	base := time.Now().Add(-time.Hour).Truncate(time.Microsecond)
+	photos := []models.Photo{
+		{Title: "bob-1", PhotoUrl: "https://example.com/1.jpg", UserID: bob.ID, CreatedAt: base},
+		{Title: "alice-1", PhotoUrl: "https://example.com/2.jpg", UserID: alice.ID, CreatedAt: base.Add(time.Minute)},
+		{Title: "carol-1", PhotoUrl: "https://example.com/3.jpg", UserID: carol.ID, CreatedAt: base.Add(2 * time.Minute)},
+		{Title: "bob-2", PhotoUrl: "https://example.com/4.jpg", UserID: bob.ID, CreatedAt: base.Add(3 * time.Minute)},
+		{Title: "bob-3", PhotoUrl: "https://example.com/5.jpg", UserID: bob.ID, CreatedAt: base.Add(4 * time.Minute)},
+	}
+	assert.NoError(t, testDB.Create(&photos).Error)
+	assert.NoError(t, testDB.Create(&models.Comment{UserID: alice.ID, PhotoID: photos[3].ID, Message: "nice"}).Error)
+
+	type feedPage struct {
+		Data dto.FeedResponse `json:"data"`
+	}
+	getFeed := func(query string) feedPage {
+		req := httptest.NewRequest(http.MethodGet, "/feed"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+aliceToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page feedPage
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page
+	}
+	titles := func(page feedPage) []string {
+		var out []string
+		for _, p := range page.Data.Photos {
+			out = append(out, p.Title)
+		}
+		return out
+	}
+
+	// 1. First page: newest first with owner and counts
+	first := getFeed("?limit=2")
+	assert.Equal(t, []string{"bob-3", "bob-2"}, titles(first))
+	assert.True(t, first.Data.HasMore)
+	assert.NotEmpty(t, first.Data.NextCursor)
+	assert.Equal(t, bob.Username, first.Data.Photos[0].Owner.Username)
+	assert.Equal(t, int64(1), first.Data.Photos[1].CommentCount)
+
+	// 2. Second page continues after the cursor and ends the feed
+	second := getFeed("?limit=2&cursor=" + first.Data.NextCursor)
+	assert.Equal(t, []string{"alice-1", "bob-1"}, titles(second))
+	assert.False(t, second.Data.HasMore)
+	assert.Empty(t, second.Data.NextCursor)
+
+	// 3. Invalid cursor
+	req := httptest.NewRequest(http.MethodGet, "/feed?cursor=not-a-cursor", nil)
+	req.Header.Set("Authorization", "Bearer "+aliceToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestExplore_RanksAndExcludesHiddenAuthors(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	alice := createTestUser(t, testDB, "alice@example.com", "password123")
+	bob := createTestUser(t, testDB, "bob@example.com", "password123")
+	carol := createTestUser(t, testDB, "carol@example.com", "password123")
+	dave := createTestUser(t, testDB, "dave@example.com", "password123")
+	erin := createTestUser(t, testDB, "erin@example.com", "password123")
+	assert.NoError(t, testDB.Model(&dave).Update("is_private", true).Error)
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+	aliceToken := loginToken(t, router, "alice@example.com", "password123")
+	erinToken := loginToken(t, router, "erin@example.com", "password123")
+
+	// Alice mutes Carol, Erin blocks Alice
+	assert.Equal(t, http.StatusOK, postJSON(router, "/users/"+carol.ID.String()+"/mute", nil, aliceToken).Code)
+	assert.Equal(t, http.StatusOK, postJSON(router, "/users/"+alice.ID.String()+"/block", nil, erinToken).Code)
+
+	now := time.Now()
+	photo := func(title string, owner models.User, age time.Duration, likes int64) models.Photo {
+		return models.Photo{Title: title, PhotoUrl: "https://example.com/" + title + ".jpg", UserID: owner.ID, LikeCount: likes, CreatedAt: now.Add(-age)}
+	}
+	photos := []models.Photo{
+		photo("bob-quiet", bob, time.Hour, 0),
+		photo("bob-hot", bob, 2*time.Hour, 0),
+		photo("bob-old", bob, 100*time.Hour, 50),
+		photo("alice-own", alice, time.Hour, 50),
+		photo("carol-muted", carol, time.Hour, 50),
+		photo("dave-private", dave, time.Hour, 50),
+		photo("erin-blocked", erin, time.Hour, 50),
+	}
+	assert.NoError(t, testDB.Create(&photos).Error)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, testDB.Create(&models.Comment{UserID: alice.ID, PhotoID: photos[1].ID, Message: "wow"}).Error)
+	}
+
+	// A view counts once for non-owners
+	assert.Equal(t, http.StatusOK, postJSON(router, "/photos/"+photos[1].ID.String()+"/view", nil, aliceToken).Code)
+	var viewed models.Photo
+	assert.NoError(t, testDB.First(&viewed, "id = ?", photos[1].ID).Error)
+	assert.Equal(t, int64(1), viewed.ViewCount)
+
+	getExplore := func(query string) dto.ExploreResponse {
+		req := httptest.NewRequest(http.MethodGet, "/explore"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+aliceToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body struct {
+			Data dto.ExploreResponse `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body.Data
+	}
+
+	page := getExplore("")
+	var titles []string
+	for _, p := range page.Photos {
+		titles = append(titles, p.Title)
+	}
+	assert.Equal(t, []string{"bob-hot", "bob-quiet"}, titles)
+	assert.False(t, page.HasMore)
+
+	page = getExplore("?limit=1")
+	assert.Len(t, page.Photos, 1)
+	assert.True(t, page.HasMore)
+
+	// Blocks also prevent following
+	w := postJSON(router, "/users/"+erin.ID.String()+"/follow", nil, aliceToken)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestPhotoLikes_IdempotentWithCounter(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	alice := createTestUser(t, testDB, "alice@example.com", "password123")
+	createTestUser(t, testDB, "bob@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+	aliceToken := loginToken(t, router, "alice@example.com", "password123")
+	bobToken := loginToken(t, router, "bob@example.com", "password123")
+
+	photo := models.Photo{Title: "sunset", PhotoUrl: "https://example.com/sunset.jpg", UserID: alice.ID}
+	assert.NoError(t, testDB.Create(&photo).Error)
+	likePath := "/photos/" + photo.ID.String() + "/like"
+
+	send := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	likeCount := func() int64 {
+		var stored models.Photo
+		assert.NoError(t, testDB.First(&stored, "id = ?", photo.ID).Error)
+		return stored.LikeCount
+	}
+
+	// 1. Liking twice counts once
+	w := postJSON(router, likePath, nil, bobToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = postJSON(router, likePath, nil, bobToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"like_count":1`)
+	postJSON(router, likePath, nil, aliceToken)
+	assert.Equal(t, int64(2), likeCount())
+
+	// 2. liked_by_me is per caller
+	w = send(http.MethodGet, "/photos", bobToken)
+	assert.Contains(t, w.Body.String(), `"like_count":2,"liked_by_me":true`)
+
+	// 3. Likes list
+	w = send(http.MethodGet, "/photos/"+photo.ID.String()+"/likes", aliceToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"total":2`)
+	assert.Contains(t, w.Body.String(), `"username":"bob@example.com"`)
+
+	// 4. Unliking twice also counts once
+	assert.Equal(t, http.StatusOK, send(http.MethodDelete, likePath, bobToken).Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodDelete, likePath, bobToken).Code)
+	assert.Equal(t, int64(1), likeCount())
+
+	w = send(http.MethodGet, "/photos", bobToken)
+	assert.Contains(t, w.Body.String(), `"like_count":1,"liked_by_me":false`)
+}
+
+func TestCommentReactions_CountsPerType(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	alice := createTestUser(t, testDB, "alice@example.com", "password123")
+	createTestUser(t, testDB, "bob@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+	aliceToken := loginToken(t, router, "alice@example.com", "password123")
+	bobToken := loginToken(t, router, "bob@example.com", "password123")
+
+	photo := models.Photo{Title: "sunset", PhotoUrl: "https://example.com/sunset.jpg", UserID: alice.ID}
+	assert.NoError(t, testDB.Create(&photo).Error)
+	comment := models.Comment{UserID: alice.ID, PhotoID: photo.ID, Message: "first"}
+	assert.NoError(t, testDB.Create(&comment).Error)
+	reply := models.Comment{UserID: alice.ID, PhotoID: photo.ID, Message: "reply", ParentCommentID: &comment.ID}
+	assert.NoError(t, testDB.Create(&reply).Error)
+
+	react := func(commentID uuid.UUID, reaction, token string) *httptest.ResponseRecorder {
+		return postJSON(router, "/comments/"+commentID.String()+"/reactions", map[string]string{"type": reaction}, token)
+	}
+	get := func(path, token string) string {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	// 1. One reaction per type per user; several types allowed
+	assert.Equal(t, http.StatusOK, react(comment.ID, "like", bobToken).Code)
+	assert.Equal(t, http.StatusOK, react(comment.ID, "like", bobToken).Code)
+	assert.Equal(t, http.StatusOK, react(comment.ID, "love", bobToken).Code)
+	w := react(comment.ID, "like", aliceToken)
+	assert.Contains(t, w.Body.String(), `"reactions":{"like":2,"love":1}`)
+	assert.Equal(t, http.StatusBadRequest, react(comment.ID, "thumbs-sideways", bobToken).Code)
+
+	// 2. Replies can be reacted to as well
+	assert.Equal(t, http.StatusOK, react(reply.ID, "haha", bobToken).Code)
+
+	// 3. Counts appear in GetAll and GetReplies
+	body := get("/comments", bobToken)
+	assert.Contains(t, body, `"reactions":{"like":2,"love":1},"my_reactions":["like","love"]`)
+	body = get("/comments/"+comment.ID.String()+"/replies", aliceToken)
+	assert.Contains(t, body, `"reactions":{"haha":1}`)
+
+	// 4. Removing a reaction
+	req := httptest.NewRequest(http.MethodDelete, "/comments/"+comment.ID.String()+"/reactions/like", nil)
+	req.Header.Set("Authorization", "Bearer "+bobToken)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"reactions":{"like":1,"love":1},"my_reactions":["love"]`)
+}
+
+func TestPhotos_MultipartUpload(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+	t.Setenv("PHOTO_MAX_UPLOAD_SIZE", "2048")
+
+	testDB := setupInMemoryDB(t)
+	createTestUser(t, testDB, "alice@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	local := &helpers.LocalStorage{Dir: t.TempDir(), PublicURL: "http://localhost:8080/uploads"}
+	originalStorage := helpers.GetStorage
+	helpers.GetStorage = func() helpers.Storage { return local }
+	t.Cleanup(func() { helpers.GetStorage = originalStorage })
+
+	router := SetupRouter()
+	token := loginToken(t, router, "alice@example.com", "password123")
+
+	upload := func(fileName string, data []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		form.WriteField("title", "sunset")
+		form.WriteField("caption", "uploaded")
+		part, _ := form.CreateFormFile("photo", fileName)
+		part.Write(data)
+		form.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/photos", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	var pngData bytes.Buffer
+	assert.NoError(t, pngEncode(&pngData))
+
+	// 1. Valid PNG (named .jpg: the type comes from the bytes, not the name)
+	w := upload("holiday.jpg", pngData.Bytes())
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data dto.PhotoResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, models.PhotoStatusProcessing, created.Data.Status)
+	assert.Nil(t, created.Data.Variants)
+
+	// The pipeline replaces the original upload with the variants
+	var stored models.Photo
+	assert.Eventually(t, func() bool {
+		return testDB.First(&stored, "id = ?", created.Data.ID).Error == nil && stored.Status == models.PhotoStatusReady
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, 300, stored.Width)
+	assert.Equal(t, 200, stored.Height)
+	assert.True(t, strings.HasPrefix(stored.PhotoUrl, "http://localhost:8080/uploads/photos/"))
+	assert.True(t, strings.HasSuffix(stored.ThumbnailURL, "/thumbnail.png"))
+	assert.Empty(t, stored.StorageKey)
+	originals, _ := filepath.Glob(filepath.Join(local.Dir, "originals", "*"))
+	assert.Empty(t, originals, "original upload is removed after processing")
+	for _, key := range stored.StoredKeys() {
+		_, err := os.Stat(filepath.Join(local.Dir, filepath.FromSlash(key)))
+		assert.NoError(t, err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/photos", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Contains(t, w.Body.String(), `"status":"ready","width":300,"height":200,"variants":{"thumbnail":"http://localhost:8080/uploads/photos/`)
+
+	// 2. Not an image
+	w = upload("evil.png", []byte("<?php echo 'hi'; ?>"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	// 3. Too large
+	w = upload("big.png", append(pngData.Bytes(), make([]byte, 4096)...))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	// 4. Deleting the photo removes the files
+	req = httptest.NewRequest(http.MethodDelete, "/photos/"+created.Data.ID, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	for _, key := range stored.StoredKeys() {
+		_, err := os.Stat(filepath.Join(local.Dir, filepath.FromSlash(key)))
+		assert.True(t, os.IsNotExist(err))
+	}
+}
+
+// pngEncode writes a 300x200 PNG
+func pngEncode(w io.Writer) error {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: color.RGBA{R: 255, A: 255}}, image.Point{}, draw.Src)
+	return png.Encode(w, img)
+}
+
+func TestPhotos_ImportRemoteURL(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	createTestUser(t, testDB, "alice@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	local := &helpers.LocalStorage{Dir: t.TempDir(), PublicURL: "http://localhost:8080/uploads"}
+	originalStorage := helpers.GetStorage
+	helpers.GetStorage = func() helpers.Storage { return local }
+	t.Cleanup(func() { helpers.GetStorage = originalStorage })
+
+	var pngData bytes.Buffer
+	assert.NoError(t, pngEncode(&pngData))
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sunset.png" {
+			w.Write(pngData.Bytes())
+			return
+		}
+		w.Write([]byte("<html>not an image</html>"))
+	}))
+	defer remote.Close()
+
+	router := SetupRouter()
+	token := loginToken(t, router, "alice@example.com", "password123")
+
+	create := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/photos", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 1. The test server is on loopback, which the SSRF protection rejects by default
+	w := create(`{"title":"sunset","photo_url":"` + remote.URL + `/sunset.png","import":true}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), helpers.ErrRemoteHostNotAllowed.Error())
+
+	t.Setenv("PHOTO_IMPORT_ALLOW_PRIVATE_HOSTS", "true")
+
+	// 2. Non-image content is rejected
+	w = create(`{"title":"page","photo_url":"` + remote.URL + `/page.html","import":true}`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code, w.Body.String())
+
+	// 3. Imported image is copied into storage and processed like an upload
+	w = create(`{"title":"sunset","photo_url":"` + remote.URL + `/sunset.png","import":true}`)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data dto.PhotoResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, models.PhotoStatusProcessing, created.Data.Status)
+
+	var stored models.Photo
+	assert.Eventually(t, func() bool {
+		return testDB.First(&stored, "id = ?", created.Data.ID).Error == nil && stored.Status == models.PhotoStatusReady
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.True(t, strings.HasPrefix(stored.PhotoUrl, "http://localhost:8080/uploads/photos/"))
+	assert.NotContains(t, stored.PhotoUrl, remote.URL)
+
+	// 4. Without import the URL is saved as-is
+	w = create(`{"title":"linked","photo_url":"` + remote.URL + `/sunset.png"}`)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"photo_url":"`+remote.URL+`/sunset.png"`)
+}
+
+func TestListEndpoints_CursorSortAndFilters(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	alice := createTestUser(t, testDB, "alice@example.com", "password123")
+	bob := createTestUser(t, testDB, "bob@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+	token := loginToken(t, router, "alice@example.com", "password123")
+
+	// Two photos share a timestamp so the id tie-breaker is exercised
+	base := time.Now().Add(-time.Hour).Truncate(time.Microsecond)
+	photos := []models.Photo{
+		{Title: "e", PhotoUrl: "https://example.com/1.jpg", UserID: alice.ID, CreatedAt: base},
+		{Title: "d", PhotoUrl: "https://example.com/2.jpg", UserID: bob.ID, CreatedAt: base.Add(time.Minute)},
+		{Title: "c", PhotoUrl: "https://example.com/3.jpg", UserID: alice.ID, CreatedAt: base.Add(time.Minute)},
+		{Title: "b", PhotoUrl: "https://example.com/4.jpg", UserID: bob.ID, CreatedAt: base.Add(2 * time.Minute)},
+		{Title: "a", PhotoUrl: "https://example.com/5.jpg", UserID: alice.ID, CreatedAt: base.Add(3 * time.Minute)},
+	}
+	assert.NoError(t, testDB.Create(&photos).Error)
+
+	type listPage struct {
+		Data []dto.PhotoResponse `json:"data"`
+		Meta dto.ListMeta        `json:"meta"`
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	// walk follows next_cursor until has_more is false and returns the titles in order
+	walk := func(query string) []string {
+		var titles []string
+		cursor := ""
+		for i := 0; i < 10; i++ {
+			w := get("/photos?limit=2" + query + "&cursor=" + cursor)
+			assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			var page listPage
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+			assert.Equal(t, 2, page.Meta.Limit)
+			for _, ph := range page.Data {
+				titles = append(titles, ph.Title)
+			}
+			if !page.Meta.HasMore {
+				assert.Empty(t, page.Meta.NextCursor)
+				return titles
+			}
+			cursor = page.Meta.NextCursor
+		}
+		t.Fatal("pagination did not terminate")
+		return nil
+	}
+
+	// 1. Default newest first; every photo exactly once across pages
+	newest := walk("")
+	assert.Len(t, newest, 5)
+	assert.Equal(t, "a", newest[0])
+	assert.Equal(t, "b", newest[1])
+	assert.ElementsMatch(t, []string{"c", "d"}, newest[2:4])
+	assert.Equal(t, "e", newest[4])
+
+	// 2. Other sorts and filters
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, walk("&sort=title"))
+	assert.Equal(t, []string{"e", "d", "c", "b", "a"}, walk("&sort=-title"))
+	assert.Equal(t, []string{"a", "c", "e"}, walk("&sort=title&user_id="+alice.ID.String()))
+	after := base.Add(90 * time.Second).UTC().Format(time.RFC3339)
+	assert.Equal(t, []string{"a", "b"}, walk("&sort=title&created_after="+after))
+
+	// 3. Invalid parameters
+	w := get("/photos?sort=password")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid sort")
+	w = get("/photos?cursor=garbage")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = get("/photos?user_id=nope")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var first listPage
+	assert.NoError(t, json.Unmarshal(get("/photos?limit=2").Body.Bytes(), &first))
+	w = get("/photos?limit=2&sort=title&cursor=" + first.Meta.NextCursor)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Cursor does not match sort")
+
+	// 4. Comments and social media use the same layer
+	comments := []models.Comment{
+		{UserID: bob.ID, PhotoID: photos[0].ID, Message: "one"},
+		{UserID: bob.ID, PhotoID: photos[1].ID, Message: "two"},
+	}
+	assert.NoError(t, testDB.Create(&comments).Error)
+	assert.NoError(t, testDB.Create(&models.Comment{UserID: bob.ID, PhotoID: photos[0].ID, Message: "reply", ParentCommentID: &comments[0].ID}).Error)
+	w = get("/comments?photo_id=" + photos[0].ID.String())
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"message":"one"`)
+	assert.Contains(t, w.Body.String(), `"replies_count":1`)
+	assert.NotContains(t, w.Body.String(), `"message":"reply"`)
+	assert.NotContains(t, w.Body.String(), `"message":"two"`)
+	assert.Contains(t, w.Body.String(), `"meta":{"limit":20,"has_more":false}`)
+
+	w = get("/socialmedias?sort=name&limit=1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"data":[]`)
+}
+
+func TestDetailEndpoints_Includes(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	alice := createTestUser(t, testDB, "alice@example.com", "password123")
+	bob := createTestUser(t, testDB, "bob@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+	token := loginToken(t, router, "alice@example.com", "password123")
+
+	photo := models.Photo{Title: "sunset", PhotoUrl: "https://example.com/1.jpg", UserID: alice.ID}
+	assert.NoError(t, testDB.Create(&photo).Error)
+	comment := models.Comment{UserID: bob.ID, PhotoID: photo.ID, Message: "nice"}
+	assert.NoError(t, testDB.Create(&comment).Error)
+	reply := models.Comment{UserID: alice.ID, PhotoID: photo.ID, Message: "thanks", ParentCommentID: &comment.ID}
+	assert.NoError(t, testDB.Create(&reply).Error)
+	social := models.SocialMedia{Name: "Instagram", SocialMediaUrl: "https://instagram.com/alice", UserID: alice.ID}
+	assert.NoError(t, testDB.Create(&social).Error)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 1. Photo without and with expansions
+	w := get("/photos/" + photo.ID.String())
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), `"user":`)
+	assert.NotContains(t, w.Body.String(), `"comments":`)
+
+	w = get("/photos/" + photo.ID.String() + "?include=user,replies")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var photoResp struct {
+		Data dto.PhotoDetailResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &photoResp))
+	assert.Equal(t, alice.Username, photoResp.Data.User.Username)
+	assert.Len(t, photoResp.Data.Comments, 1, "replies are nested, not listed as top-level comments")
+	assert.Equal(t, 1, photoResp.Data.Comments[0].RepliesCount)
+	assert.Len(t, photoResp.Data.Comments[0].Replies, 1)
+	assert.Equal(t, "thanks", photoResp.Data.Comments[0].Replies[0].Message)
+	assert.NotContains(t, w.Body.String(), "password")
+
+	// 2. Comment with its photo and replies; a reply has an empty replies list
+	w = get("/comments/" + comment.ID.String() + "?include=photo,replies,user")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var commentResp struct {
+		Data dto.CommentDetailResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &commentResp))
+	assert.Equal(t, "sunset", commentResp.Data.Photo.Title)
+	assert.Equal(t, bob.Username, commentResp.Data.User.Username)
+	assert.Len(t, commentResp.Data.Replies, 1)
+
+	w = get("/comments/" + reply.ID.String() + "?include=replies")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"replies":[]`)
+	assert.Contains(t, w.Body.String(), `"parent_comment_id":"`+comment.ID.String()+`"`)
+
+	// 3. Social media
+	w = get("/socialmedias/" + social.ID.String() + "?include=user")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"name":"Instagram"`)
+	assert.Contains(t, w.Body.String(), `"user":{"id":"`+alice.ID.String()+`"`)
+
+	// 4. Validation and missing resources
+	w = get("/photos/" + photo.ID.String() + "?include=user,likes")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `Invalid include \"likes\", allowed: user, comments, replies`)
+	w = get("/socialmedias/" + social.ID.String() + "?include=comments")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = get("/comments/not-a-uuid")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = get("/photos/" + uuid.NewString())
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPhotoDetail_LimitsRepliesPerComment(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	alice := createTestUser(t, testDB, "alice@example.com", "password123")
+	bob := createTestUser(t, testDB, "bob@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+	token := loginToken(t, router, "alice@example.com", "password123")
+
+	photo := models.Photo{Title: "busy", PhotoUrl: "https://example.com/1.jpg", UserID: alice.ID}
+	assert.NoError(t, testDB.Create(&photo).Error)
+	busy := models.Comment{UserID: bob.ID, PhotoID: photo.ID, Message: "busy thread"}
+	assert.NoError(t, testDB.Create(&busy).Error)
+	quiet := models.Comment{UserID: bob.ID, PhotoID: photo.ID, Message: "quiet thread"}
+	assert.NoError(t, testDB.Create(&quiet).Error)
+
+	// 101 replies on one comment, 2 on the other
+	base := time.Now().Add(-time.Hour)
+	var replies []models.Comment
+	for i := 0; i < 101; i++ {
+		replies = append(replies, models.Comment{UserID: alice.ID, PhotoID: photo.ID, Message: fmt.Sprintf("reply %d", i), ParentCommentID: &busy.ID, CreatedAt: base.Add(time.Duration(i) * time.Second)})
+	}
+	for i := 0; i < 2; i++ {
+		replies = append(replies, models.Comment{UserID: alice.ID, PhotoID: photo.ID, Message: "quiet reply", ParentCommentID: &quiet.ID})
+	}
+	assert.NoError(t, testDB.Create(&replies).Error)
+
+	req := httptest.NewRequest(http.MethodGet, "/photos/"+photo.ID.String()+"?include=replies,user", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Data dto.PhotoDetailResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	byMessage := map[string]dto.CommentDetailResponse{}
+	for _, cm := range resp.Data.Comments {
+		byMessage[cm.Message] = cm
+	}
+
+	// The cap applies per comment: the busy thread is cut at 100 (oldest first), the quiet one is complete
+	assert.Len(t, resp.Data.Comments, 2)
+	assert.Equal(t, 101, byMessage["busy thread"].RepliesCount)
+	if assert.Len(t, byMessage["busy thread"].Replies, 100) {
+		assert.Equal(t, "reply 0", byMessage["busy thread"].Replies[0].Message)
+		assert.Equal(t, "reply 99", byMessage["busy thread"].Replies[99].Message)
+	}
+	assert.Len(t, byMessage["quiet thread"].Replies, 2)
+
+	// include=user also expands the authors of comments and replies
+	if assert.NotNil(t, byMessage["quiet thread"].User) {
+		assert.Equal(t, bob.Username, byMessage["quiet thread"].User.Username)
+	}
+	if assert.NotNil(t, byMessage["quiet thread"].Replies[0].User) {
+		assert.Equal(t, alice.Username, byMessage["quiet thread"].Replies[0].User.Username)
+	}
+}
+
+func TestPhotoDetail_HiddenFromNonFollowersAndBlockedUsers(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+
+	testDB := setupInMemoryDB(t)
+	owner := createTestUser(t, testDB, "detail-owner@example.com", "password123")
+	assert.NoError(t, testDB.Model(&owner).Update("is_private", true).Error)
+	blocked := createTestUser(t, testDB, "detail-blocked@example.com", "password123")
+	createTestUser(t, testDB, "detail-stranger@example.com", "password123")
+	assert.NoError(t, testDB.Create(&models.UserBlock{UserID: blocked.ID, TargetID: owner.ID, Kind: models.BlockKindBlock}).Error)
+	photo := models.Photo{Title: "detail", PhotoUrl: "https://example.com/detail.jpg", UserID: owner.ID}
+	assert.NoError(t, testDB.Create(&photo).Error)
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+
+	get := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/photos/"+photo.ID.String()+"?include=user,comments", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, get(loginToken(t, router, "detail-owner@example.com", "password123")))
+	assert.Equal(t, http.StatusForbidden, get(loginToken(t, router, "detail-stranger@example.com", "password123")))
+
+	// Public again: only the block keeps the photo hidden
+	assert.NoError(t, testDB.Model(&owner).Update("is_private", false).Error)
+	assert.Equal(t, http.StatusOK, get(loginToken(t, router, "detail-stranger@example.com", "password123")))
+	assert.Equal(t, http.StatusNotFound, get(loginToken(t, router, "detail-blocked@example.com", "password123")))
+}
+
+func TestPhotoComments_SortAndThread(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "testsecret")
+	t.Setenv("COMMENT_THREAD_MAX_DEPTH", "3")
+
+	testDB := setupInMemoryDB(t)
+	alice := createTestUser(t, testDB, "alice@example.com", "password123")
+	bob := createTestUser(t, testDB, "bob@example.com", "password123")
+	database.GetDB = func() *gorm.DB {
+		return testDB
+	}
+	router := SetupRouter()
+	token := loginToken(t, router, "alice@example.com", "password123")
+
+	photo := models.Photo{Title: "sunset", PhotoUrl: "https://example.com/1.jpg", UserID: alice.ID}
+	assert.NoError(t, testDB.Create(&photo).Error)
+
+	// 1. Comments require an existing photo
+	w := postJSON(router, "/comments", map[string]string{"photo_id": uuid.NewString(), "message": "hi"}, token)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "Photo not found")
+
+	// first (2 replies), second (none), third (1 reply, which has its own reply chain)
+	base := time.Now().Add(-time.Hour)
+	comment := func(message string, userID uuid.UUID, parent *models.Comment, offset time.Duration) models.Comment {
+		cm := models.Comment{UserID: userID, PhotoID: photo.ID, Message: message, CreatedAt: base.Add(offset)}
+		if parent != nil {
+			cm.ParentCommentID = &parent.ID
+		}
+		assert.NoError(t, testDB.Create(&cm).Error)
+		return cm
+	}
+	first := comment("first", bob.ID, nil, 0)
+	comment("first-a", alice.ID, &first, 10*time.Minute)
+	comment("first-b", bob.ID, &first, 11*time.Minute)
+	comment("second", alice.ID, nil, time.Minute)
+	third := comment("third", bob.ID, nil, 2*time.Minute)
+	level1 := comment("third-1", alice.ID, &third, 12*time.Minute)
+	level2 := comment("third-1-1", bob.ID, &level1, 13*time.Minute)
+	level3 := comment("third-1-1-1", alice.ID, &level2, 14*time.Minute)
+	comment("third-1-1-1-1", bob.ID, &level3, 15*time.Minute)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	type commentPage struct {
+		Data []dto.CommentDetailResponse `json:"data"`
+		Meta dto.ListMeta                `json:"meta"`
+	}
+	list := func(sort string) []string {
+		var messages []string
+		cursor := ""
+		for i := 0; i < 5; i++ {
+			w := get("/photos/" + photo.ID.String() + "/comments?limit=2&sort=" + sort + "&cursor=" + cursor)
+			assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			var page commentPage
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+			for _, cm := range page.Data {
+				assert.NotNil(t, cm.User)
+				messages = append(messages, cm.Message)
+			}
+			if !page.Meta.HasMore {
+				return messages
+			}
+			cursor = page.Meta.NextCursor
+		}
+		t.Fatal("pagination did not terminate")
+		return nil
+	}
+
+	// 2. Top-level comments of the photo in each sort order
+	assert.Equal(t, []string{"third", "second", "first"}, list("newest"))
+	assert.Equal(t, []string{"first", "second", "third"}, list("oldest"))
+	assert.Equal(t, []string{"first", "third", "second"}, list("most_replied"))
+
+	w = get("/photos/" + photo.ID.String() + "/comments?sort=-created_at")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "most_replied, newest, oldest")
+	w = get("/photos/" + uuid.NewString() + "/comments")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 3. Thread of "third" down to the configured max depth (3)
+	w = get("/comments/" + third.ID.String() + "/thread")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var thread struct {
+		Data dto.CommentThreadResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &thread))
+	assert.Equal(t, "third", thread.Data.Message)
+	assert.False(t, thread.Data.Truncated)
+	assert.Equal(t, bob.Username, thread.Data.User.Username)
+	node := thread.Data.CommentDetailResponse
+	for _, message := range []string{"third-1", "third-1-1", "third-1-1-1"} {
+		assert.Len(t, node.Replies, 1)
+		node = node.Replies[0]
+		assert.Equal(t, message, node.Message)
+	}
+	assert.Empty(t, node.Replies, "replies below the max depth are not loaded")
+	assert.Equal(t, 1, node.RepliesCount)
+
+	// 4. Shallower depth, siblings oldest first
+	w = get("/comments/" + first.ID.String() + "/thread?depth=1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &thread))
+	assert.Len(t, thread.Data.Replies, 2)
+	assert.Equal(t, "first-a", thread.Data.Replies[0].Message)
+	assert.Equal(t, "first-b", thread.Data.Replies[1].Message)
+
+	w = get("/comments/" + first.ID.String() + "/thread?depth=4")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = get("/comments/" + uuid.NewString() + "/thread")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 5. The node limit cuts the tree level by level and flags it
+	t.Setenv("COMMENT_THREAD_MAX_NODES", "2")
+	w = get("/comments/" + first.ID.String() + "/thread")
+	assert.Equal(t, http.StatusOK, w.Code)
+	thread.Data = dto.CommentThreadResponse{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &thread))
+	assert.True(t, thread.Data.Truncated)
+	if assert.Len(t, thread.Data.Replies, 1) {
+		assert.Equal(t, "first-a", thread.Data.Replies[0].Message)
+	}
+	assert.Equal(t, 2, thread.Data.RepliesCount)
+}
+
+func TestSetupRouter_SwaggerEndpointExists(t *testing.T) {
+	t.Parallel()
+
+	r := SetupRouter()
+	req := httptest.NewRequest("GET", "/swagger/index.html", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// We just assert that the route isn't 404. The swagger handler serves static UI.
+	assert.NotEqual(t, http.StatusNotFound, w.Code, "swagger endpoint should be registered")
+}
+
+func TestSetupRouter_JWKSEndpoint(t *testing.T) {
+	t.Parallel()
+
+	r := SetupRouter()
+	req := httptest.NewRequest("GET", "/.well-known/jwks.json", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"keys"`)
 }